@@ -4,41 +4,90 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"runtime"
+	"strings"
+	"time"
 
+	"github.com/jenkins-x/sso-operator/pkg/dcr"
+	"github.com/jenkins-x/sso-operator/pkg/defaults"
 	"github.com/jenkins-x/sso-operator/pkg/dex"
+	"github.com/jenkins-x/sso-operator/pkg/health"
+	"github.com/jenkins-x/sso-operator/pkg/idp"
+	"github.com/jenkins-x/sso-operator/pkg/keycloak"
 	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/jenkins-x/sso-operator/pkg/leader"
 	"github.com/jenkins-x/sso-operator/pkg/operator"
-	sdk "github.com/operator-framework/operator-sdk/pkg/sdk"
-	sdkVersion "github.com/operator-framework/operator-sdk/version"
+	"github.com/jenkins-x/sso-operator/pkg/webhook"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/discovery"
 )
 
 const (
 	watchNamespaceEnv    = "WATCH_NAMESPACE"
 	operatorNamespaceEnv = "OPERATOR_NAMESPACE"
 	port                 = "8080"
+	healthCheckTimeout   = 3 * time.Second
+
+	identityProviderDex      = "dex"
+	identityProviderDCR      = "dcr"
+	identityProviderKeycloak = "keycloak"
+	idpRequestTimeout        = 30 * time.Second
 )
 
 // OperatorOptions holds the command options for SSO operator
 type OperatorOptions struct {
 	Namespace          string
 	WatchNamespace     string
+	IdentityProvider   string
 	DexGrpcHostAndPort string
 	DexGrpcClientCrt   string
 	DexGrpcClientKey   string
 	DexGrpcClientCA    string
+	DexGrpcTimeout     time.Duration
+	DexGrpcRetries     int
+	DexGrpcKeepalive   time.Duration
 	ClusterRoleName    string
+	ClientGCInterval   time.Duration
+	ClientGCDryRun     bool
+	CookieMinInterval  time.Duration
+	WebhookAddr        string
+	WebhookCertFile    string
+	WebhookKeyFile     string
+	Workers            int
+	ResyncPeriod       time.Duration
+
+	DCRRegistrationURL        string
+	DCRInitialAccessTokenFile string
+
+	KeycloakURL              string
+	KeycloakRealm            string
+	KeycloakAdminRealm       string
+	KeycloakClientID         string
+	KeycloakClientSecretFile string
+	KeycloakUsername         string
+	KeycloakPasswordFile     string
+	KeycloakGroupsClaim      string
+	KeycloakRolesClaim       string
+
+	LeaderElect                 bool
+	LeaderElectionID            string
+	LeaderElectionNamespace     string
+	LeaderElectionLeaseDuration time.Duration
+	LeaderElectionRenewDeadline time.Duration
+	LeaderElectionRetryPeriod   time.Duration
+	Defaults                    defaults.Defaults
 }
 
 func printVersion(namespace string, watchNamespace string) {
 	logrus.Infof("Go Version: %s", runtime.Version())
 	logrus.Infof("Go OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH)
-	logrus.Infof("operator-sdk Version: %v", sdkVersion.Version)
 	logrus.Infof("operator namespace: %s", namespace)
 	if watchNamespace == "" {
 		logrus.Info("operator watching entire cluster")
@@ -47,12 +96,43 @@ func printVersion(namespace string, watchNamespace string) {
 	}
 }
 
-func handleLiveness() {
-	logrus.Infof("Liveness probe listening on: %s", port)
+// handleHealth serves the health probes and the metrics. The liveness probe checks the Kubernetes API, the
+// readiness probe checks the identity provider as well, and the root path reports if the replica is the leader
+// or a follower.
+func handleHealth(isLeader func() bool, idpChecks map[string]health.Check) {
+	// The API server is not given more time than the probe to answer
+	config, err := kubernetes.GetClientConfig()
+	if err != nil {
+		logrus.Errorf("failed to create the k8s config of the health probes: %v", err)
+		os.Exit(2)
+	}
+	config.Timeout = healthCheckTimeout
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		logrus.Errorf("failed to create the k8s client of the health probes: %v", err)
+		os.Exit(2)
+	}
+	k8sCheck := health.Kubernetes(discoveryClient)
+
+	logrus.Infof("Health probes listening on: %s", port)
+	http.Handle("/metrics", promhttp.Handler())
+	http.Handle("/healthz", health.Handler(healthCheckTimeout, map[string]health.Check{
+		"kubernetes": k8sCheck,
+	}))
+	readyChecks := map[string]health.Check{"kubernetes": k8sCheck}
+	for name, check := range idpChecks {
+		readyChecks[name] = check
+	}
+	http.Handle("/readyz", health.Handler(healthCheckTimeout, readyChecks))
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		logrus.Debug("ping")
+		if isLeader() {
+			fmt.Fprintln(w, "leader")
+		} else {
+			fmt.Fprintln(w, "follower")
+		}
 	})
-	err := http.ListenAndServe(":"+port, nil) // #nosec
+	err = http.ListenAndServe(":"+port, nil) // #nosec
 	if err != nil {
 		logrus.Errorf("failed to start health probe: %v\n", err)
 	}
@@ -77,50 +157,240 @@ func (o *OperatorOptions) Run() {
 		os.Exit(2)
 	}
 
-	opts := &dex.Options{
-		HostAndPort: o.DexGrpcHostAndPort,
-		ClientCrt:   o.DexGrpcClientCrt,
-		ClientKey:   o.DexGrpcClientKey,
-		ClientCA:    o.DexGrpcClientCA,
-	}
-	dexClient, err := dex.NewClient(opts)
+	clients, idpChecks, err := o.newRegistrar(namespace)
 	if err != nil {
-		logrus.Errorf("failed to crate dex client: %v", err)
+		logrus.Errorf("failed to create the %s client: %v", o.IdentityProvider, err)
 		os.Exit(2)
 	}
 
-	logrus.Infof("Connected to Dex gRPC server: %s", o.DexGrpcHostAndPort)
+	// start the health probe, a replica which does not elect a leader is always the leader
+	var elector *leader.Elector
+	isLeader := func() bool {
+		return elector == nil || elector.IsLeader()
+	}
+	if o.LeaderElect {
+		elector = leader.NewElector(o.leaderConfig(namespace))
+	}
+	go handleHealth(isLeader, idpChecks)
 
-	// Register the CRDs
-	apiclient, err := kubernetes.GetAPIExtensionsClient()
-	if err != nil {
-		logrus.Errorf("failed to register the k8s API extensions client: %v", err)
-		os.Exit(2)
+	// start the admission webhooks
+	if o.WebhookCertFile != "" {
+		server := webhook.NewServer(o.WebhookAddr, o.WebhookCertFile, o.WebhookKeyFile, kubernetes.GetService, &o.Defaults)
+		go func() {
+			err := server.Run(context.TODO())
+			if err != nil {
+				logrus.Errorf("failed to start the webhook server: %v", err)
+				os.Exit(2)
+			}
+		}()
+	}
+
+	// start the operator, only in the leader when several replicas are running
+	run := func(ctx context.Context) {
+		o.runOperator(ctx, clients, namespace, watchNamespace)
 	}
-	err = kubernetes.RegisterSSOCRD(apiclient)
+	if elector == nil {
+		run(context.TODO())
+		return
+	}
+	err = elector.Run(context.TODO(), run)
+	logrus.Errorf("leader election: %v", err)
+	os.Exit(1)
+}
+
+// runOperator registers the CRDs and reconciles the SSOs until the context is done
+func (o *OperatorOptions) runOperator(ctx context.Context, clients idp.Registrar, namespace string, watchNamespace string) {
+	// Register the CRDs
+	err := kubernetes.RegisterSSOCRD()
 	if err != nil {
 		logrus.Errorf("failed to register the SSO CRD: %v", err)
 		os.Exit(2)
 	}
 
 	// configure the operator
-	sdk.Watch("jenkins.io/v1", "SSO", watchNamespace, 5)
-	handler, err := operator.NewHandler(dexClient, namespace, o.ClusterRoleName)
+	mgr, err := operator.NewManager(watchNamespace, o.ResyncPeriod)
+	if err != nil {
+		logrus.Errorf("failed to create the operator manager: %v", err)
+		os.Exit(2)
+	}
+	recorder, err := kubernetes.NewEventRecorder("sso-operator")
+	if err != nil {
+		logrus.Errorf("failed to create the event recorder: %v", err)
+		os.Exit(2)
+	}
+	handler, err := operator.NewHandler(clients, namespace, o.ClusterRoleName, o.CookieMinInterval, &o.Defaults,
+		mgr.GetCache(), recorder)
 	if err != nil {
 		logrus.Errorf("failed to create the operator handler: %v", err)
 		os.Exit(2)
 	}
-	sdk.Handle(handler)
+	err = operator.NewController(mgr, handler, o.Workers)
+	if err != nil {
+		logrus.Errorf("failed to create the operator controller: %v", err)
+		os.Exit(2)
+	}
+	prometheus.MustRegister(operator.NewPhaseCollector(mgr.GetCache()))
 
-	// start the health probe
-	go handleLiveness()
+	// start the garbage collector of orphaned OIDC clients
+	if o.ClientGCInterval > 0 {
+		collector := operator.NewClientCollector(clients, namespace, watchNamespace, o.ClientGCInterval, o.ClientGCDryRun)
+		go collector.Run(ctx)
+	}
 
 	// start the operator
-	sdk.Run(context.TODO())
+	err = mgr.Start(ctx)
+	if err != nil {
+		logrus.Errorf("failed to run the operator: %v", err)
+		os.Exit(2)
+	}
+}
+
+// newRegistrar creates the client of the identity provider in which the OIDC clients are registered, together
+// with the readiness checks of the identity provider
+func (o *OperatorOptions) newRegistrar(namespace string) (idp.Registrar, map[string]health.Check, error) {
+	switch o.IdentityProvider {
+	case identityProviderDCR:
+		initialAccessToken, err := readSecretFile(o.DCRInitialAccessTokenFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading the initial access token: %v", err)
+		}
+		client, err := dcr.NewClient(&dcr.Options{
+			RegistrationURL:    o.DCRRegistrationURL,
+			InitialAccessToken: initialAccessToken,
+			Namespace:          namespace,
+			Timeout:            idpRequestTimeout,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		logrus.Infof("Registering the OIDC clients at: %s", o.DCRRegistrationURL)
+		return client, map[string]health.Check{}, nil
+	case identityProviderKeycloak:
+		clientSecret, err := readSecretFile(o.KeycloakClientSecretFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading the keycloak client secret: %v", err)
+		}
+		password, err := readSecretFile(o.KeycloakPasswordFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading the keycloak password: %v", err)
+		}
+		client, err := keycloak.NewClient(&keycloak.Options{
+			URL:               o.KeycloakURL,
+			Realm:             o.KeycloakRealm,
+			AdminRealm:        o.KeycloakAdminRealm,
+			AdminClientID:     o.KeycloakClientID,
+			AdminClientSecret: clientSecret,
+			Username:          o.KeycloakUsername,
+			Password:          password,
+			GroupsClaim:       o.KeycloakGroupsClaim,
+			RolesClaim:        o.KeycloakRolesClaim,
+			Timeout:           idpRequestTimeout,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		logrus.Infof("Creating the OIDC clients in the keycloak realm '%s' at: %s", o.KeycloakRealm, o.KeycloakURL)
+		return client, map[string]health.Check{"keycloak": client.Ping}, nil
+	}
+
+	opts := &dex.Options{
+		HostAndPort:   o.DexGrpcHostAndPort,
+		ClientCrt:     o.DexGrpcClientCrt,
+		ClientKey:     o.DexGrpcClientKey,
+		ClientCA:      o.DexGrpcClientCA,
+		Timeout:       o.DexGrpcTimeout,
+		Retries:       o.DexGrpcRetries,
+		KeepaliveTime: o.DexGrpcKeepalive,
+	}
+	dexClient, err := dex.NewClient(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Refuse to start with a dex which does not answer the version of its API or serves an unsupported version
+	ctx, cancel := context.WithTimeout(context.Background(), idpRequestTimeout)
+	defer cancel()
+	version, err := dexClient.CheckVersion(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	logrus.Infof("Connected to Dex %s gRPC server with API version %d: %s", version.GetServer(), version.GetApi(),
+		o.DexGrpcHostAndPort)
+	return dexClient, map[string]health.Check{"dex": dexClient.Ping}, nil
+}
+
+// readSecretFile reads a credential mounted from a secret, an empty path gives an empty credential
+func readSecretFile(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := ioutil.ReadFile(path) // #nosec
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// leaderConfig builds the configuration of the leader election, the lease is created in the namespace of the
+// operator unless another namespace is provided
+func (o *OperatorOptions) leaderConfig(namespace string) leader.Config {
+	leaseNamespace := o.LeaderElectionNamespace
+	if leaseNamespace == "" {
+		leaseNamespace = namespace
+	}
+	identity, err := os.Hostname()
+	if err != nil {
+		logrus.Errorf("failed to get the hostname as leader election identity: %v", err)
+		os.Exit(2)
+	}
+	return leader.Config{
+		Namespace:     leaseNamespace,
+		Name:          o.LeaderElectionID,
+		Identity:      identity,
+		LeaseDuration: o.LeaderElectionLeaseDuration,
+		RenewDeadline: o.LeaderElectionRenewDeadline,
+		RetryPeriod:   o.LeaderElectionRetryPeriod,
+	}
 }
 
 // Validate validates the provided command options
 func (o *OperatorOptions) Validate() error {
+	switch o.IdentityProvider {
+	case identityProviderDex:
+		err := o.validateDex()
+		if err != nil {
+			return err
+		}
+	case identityProviderDCR:
+		if o.DCRRegistrationURL == "" {
+			return errors.New("client registration URL of the identity provider is empty")
+		}
+	case identityProviderKeycloak:
+		if o.KeycloakURL == "" || o.KeycloakRealm == "" {
+			return errors.New("keycloak URL and realm are required")
+		}
+		if o.KeycloakClientSecretFile == "" && o.KeycloakUsername == "" {
+			return errors.New("either a keycloak client secret file or a keycloak username is required")
+		}
+	default:
+		return fmt.Errorf("unsupported identity provider '%s', supported values are: %s, %s, %s", o.IdentityProvider,
+			identityProviderDex, identityProviderDCR, identityProviderKeycloak)
+	}
+
+	if o.WebhookCertFile != "" {
+		if _, err := os.Stat(o.WebhookCertFile); os.IsNotExist(err) {
+			return fmt.Errorf("provided webhook cert file '%s' does not exist", o.WebhookCertFile)
+		}
+		if _, err := os.Stat(o.WebhookKeyFile); os.IsNotExist(err) {
+			return fmt.Errorf("provided webhook key file '%s' does not exist", o.WebhookKeyFile)
+		}
+	}
+
+	return nil
+}
+
+// validateDex validates the options of the dex gRPC client
+func (o *OperatorOptions) validateDex() error {
 	if o.DexGrpcHostAndPort == "" {
 		return errors.New("dex gRPC server host and port is empty")
 	}
@@ -136,6 +406,17 @@ func (o *OperatorOptions) Validate() error {
 		return fmt.Errorf("provided dex gRPC CA cert file '%s' does not exists", o.DexGrpcClientCA)
 	}
 
+	if o.DexGrpcTimeout <= 0 {
+		return errors.New("dex gRPC timeout must be positive")
+	}
+
+	if o.DexGrpcRetries < 0 {
+		return errors.New("dex gRPC retries cannot be negative")
+	}
+
+	if o.DexGrpcKeepalive < dex.MinKeepaliveTime {
+		return fmt.Errorf("dex gRPC keepalive time must be at least %s", dex.MinKeepaliveTime)
+	}
 	return nil
 }
 
@@ -151,11 +432,51 @@ func commandRoot() *cobra.Command {
 
 	rootCmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "Namespace where the operator where the operator is deployed")
 	rootCmd.Flags().StringVarP(&options.WatchNamespace, "watch-namespace", "", "", "Namespace where the operator will watch for resources (leave empty to watch the entire cluster)")
+	rootCmd.Flags().StringVarP(&options.IdentityProvider, "identity-provider", "", identityProviderDex, "Identity provider in which the OIDC clients are registered: dex (gRPC API), dcr (OAuth 2.0 Dynamic Client Registration) or keycloak (admin API)")
 	rootCmd.Flags().StringVarP(&options.DexGrpcHostAndPort, "dex-grpc-host-port", "", "", "Host and port of Dex gRPC server")
 	rootCmd.Flags().StringVarP(&options.DexGrpcClientCrt, "dex-grpc-client-crt", "", "", "Certificate for Dex gRPC client")
 	rootCmd.Flags().StringVarP(&options.DexGrpcClientKey, "dex-grpc-client-key", "", "", "Key for Dex gRPC client")
 	rootCmd.Flags().StringVarP(&options.DexGrpcClientCA, "dex-grpc-client-ca", "", "", "CA certificate for Dex gRPC client")
+	rootCmd.Flags().DurationVarP(&options.DexGrpcTimeout, "dex-grpc-timeout", "", 10*time.Second, "Timeout of every attempt of a Dex gRPC call")
+	rootCmd.Flags().IntVarP(&options.DexGrpcRetries, "dex-grpc-retries", "", 4, "Number of times a Dex gRPC call is retried while Dex is unavailable")
+	rootCmd.Flags().DurationVarP(&options.DexGrpcKeepalive, "dex-grpc-keepalive-time", "", dex.MinKeepaliveTime, "Interval of the keepalive pings sent to Dex while a gRPC call is in flight (at least 5m, the minimum accepted by Dex)")
+	rootCmd.Flags().StringVarP(&options.DCRRegistrationURL, "dcr-registration-url", "", "", "Client registration endpoint of the identity provider when the dcr identity provider is used")
+	rootCmd.Flags().StringVarP(&options.DCRInitialAccessTokenFile, "dcr-initial-access-token-file", "", "", "File containing the initial access token which authorizes the client registrations (optional)")
+	rootCmd.Flags().StringVarP(&options.KeycloakURL, "keycloak-url", "", "", "URL of the keycloak server, including the /auth context path of the versions which have one")
+	rootCmd.Flags().StringVarP(&options.KeycloakRealm, "keycloak-realm", "", "", "Keycloak realm in which the OIDC clients are created")
+	rootCmd.Flags().StringVarP(&options.KeycloakAdminRealm, "keycloak-admin-realm", "", "master", "Keycloak realm of the credentials of the operator")
+	rootCmd.Flags().StringVarP(&options.KeycloakClientID, "keycloak-client-id", "", "admin-cli", "Keycloak client with which the operator authenticates")
+	rootCmd.Flags().StringVarP(&options.KeycloakClientSecretFile, "keycloak-client-secret-file", "", "", "File containing the secret of the keycloak client of the operator, which authenticates with its service account")
+	rootCmd.Flags().StringVarP(&options.KeycloakUsername, "keycloak-username", "", "", "Keycloak user of the operator when no client secret is provided")
+	rootCmd.Flags().StringVarP(&options.KeycloakPasswordFile, "keycloak-password-file", "", "", "File containing the password of the keycloak user of the operator")
+	rootCmd.Flags().StringVarP(&options.KeycloakGroupsClaim, "keycloak-groups-claim", "", "groups", "Claim in which the groups of the users are mapped (leave empty to not map the groups)")
+	rootCmd.Flags().StringVarP(&options.KeycloakRolesClaim, "keycloak-roles-claim", "", "roles", "Claim in which the realm roles of the users are mapped (leave empty to not map the roles)")
 	rootCmd.Flags().StringVarP(&options.ClusterRoleName, "cluster-role-name", "", "", "Cluster role name which has the required permissions for operator")
+	rootCmd.Flags().DurationVarP(&options.ClientGCInterval, "client-gc-interval", "", 30*time.Minute, "Interval between garbage collections of orphaned OIDC clients in the identity provider (0 disables the collection)")
+	rootCmd.Flags().BoolVarP(&options.ClientGCDryRun, "client-gc-dry-run", "", false, "Only report the orphaned OIDC clients without deleting them from the identity provider")
+	rootCmd.Flags().DurationVarP(&options.CookieMinInterval, "cookie-rotation-min-interval", "", 10*time.Minute, "Minimum time between two rotations of the cookie secret of a SSO, every rotation logs out the users of the SSO")
+	rootCmd.Flags().StringVarP(&options.WebhookAddr, "webhook-addr", "", ":8443", "Address on which the admission webhooks are served")
+	rootCmd.Flags().StringVarP(&options.WebhookCertFile, "webhook-cert-file", "", "", "TLS certificate of the admission webhooks (leave empty to disable the webhooks)")
+	rootCmd.Flags().StringVarP(&options.WebhookKeyFile, "webhook-key-file", "", "", "TLS key of the admission webhooks")
+	rootCmd.Flags().IntVarP(&options.Workers, "workers", "", 2, "Number of SSOs reconciled concurrently")
+	rootCmd.Flags().DurationVarP(&options.ResyncPeriod, "resync-period", "", 5*time.Minute, "Interval between two reconciliations of a SSO which did not change")
+	rootCmd.Flags().BoolVarP(&options.LeaderElect, "leader-elect", "", false, "Elect a leader with a lease in order to run several replicas of the operator")
+	rootCmd.Flags().StringVarP(&options.LeaderElectionID, "leader-election-id", "", "sso-operator-leader", "Name of the lease used for the leader election")
+	rootCmd.Flags().StringVarP(&options.LeaderElectionNamespace, "leader-election-namespace", "", "", "Namespace of the lease used for the leader election (defaults to the operator namespace)")
+	rootCmd.Flags().DurationVarP(&options.LeaderElectionLeaseDuration, "leader-election-lease-duration", "", 15*time.Second, "Time a follower waits before taking over a lease which is not renewed")
+	rootCmd.Flags().DurationVarP(&options.LeaderElectionRenewDeadline, "leader-election-renew-deadline", "", 10*time.Second, "Time the leader retries to renew the lease before giving up the leadership")
+	rootCmd.Flags().DurationVarP(&options.LeaderElectionRetryPeriod, "leader-election-retry-period", "", 2*time.Second, "Time between two attempts to acquire or renew the lease")
+	rootCmd.Flags().StringVarP(&options.Defaults.OIDCIssuerURL, "default-oidc-issuer-url", "", "", "Default URL of dex IdP for the SSOs which do not set it")
+	rootCmd.Flags().StringVarP(&options.Defaults.Domain, "default-domain", "", "", "Default domain under which the SSO services are exposed")
+	rootCmd.Flags().StringVarP(&options.Defaults.CertIssuerName, "default-cert-issuer-name", "", "", "Default cert-manager issuer name for the SSOs which set no issuer")
+	rootCmd.Flags().StringVarP(&options.Defaults.CertClusterIssuerName, "default-cert-cluster-issuer-name", "", "", "Default cert-manager cluster issuer name for the SSOs which set no issuer")
+	rootCmd.Flags().StringVarP(&options.Defaults.URLTemplate, "default-url-template", "", "", "Default template of the public host of the SSO services")
+	rootCmd.Flags().StringVarP(&options.Defaults.ProxyImage, "default-proxy-image", "", "quay.io/pusher/oauth2_proxy", "Default Docker image for oauth2_proxy")
+	rootCmd.Flags().StringVarP(&options.Defaults.ProxyImageTag, "default-proxy-image-tag", "", "v3.2.0", "Default Docker image tag for oauth2_proxy")
+	rootCmd.Flags().StringVarP(&options.Defaults.CookieExpire, "default-cookie-expire", "", "168h", "Default expiration time of the SSO cookie")
+	rootCmd.Flags().StringVarP(&options.Defaults.CookieRefresh, "default-cookie-refresh", "", "60m", "Default refresh time of the SSO cookie")
+	rootCmd.Flags().BoolVarP(&options.Defaults.CookieSecure, "default-cookie-secure", "", true, "Send the SSO cookie only over HTTPS when the SSO has no cookie spec")
+	rootCmd.Flags().BoolVarP(&options.Defaults.CookieHTTPOnly, "default-cookie-http-only", "", true, "Hide the SSO cookie from JavaScript when the SSO has no cookie spec")
 
 	return rootCmd
 }
@@ -5,7 +5,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	jenkinsio "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io"
-	sdkK8sutil "github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -22,10 +21,6 @@ var (
 	SSOKind = "SSO"
 )
 
-func init() {
-	sdkK8sutil.AddToSDKScheme(AddToScheme)
-}
-
 // Kind takes an unqualified kind and returns back a Group qualified GroupKind
 func Kind(kind string) schema.GroupKind {
 	return SchemeGroupVersion.WithKind(kind).GroupKind()
@@ -3,10 +3,10 @@ package v1
 import (
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // +genclient
-// +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +k8s:openapi-gen=true
 
@@ -28,10 +28,18 @@ type SSOSpec struct {
 	OIDCIssuerURL string `json:"oidcIssuerUrl,omitempty"`
 	// Name of the upstream service for which the SSO is created
 	UpstreamService string `json:"upstreamService,omitempty"`
+	// Upstreams routed by path behind the same SSO proxy, used instead of the upstream service
+	Upstreams []Upstream `json:"upstreams,omitempty"`
 	// Domain name under which the SSO service will be exposed
 	Domain string `json:"domain,omitempty"`
 	// cert-manager issuer name
 	CertIssuerName string `json:"certIssuerName,omitempty"`
+	// cert-manager cluster issuer name
+	CertClusterIssuerName string `json:"certClusterIssuerName,omitempty"`
+	// IngressClassName is the class of the ingress controller which exposes the SSO proxy, defaults to nginx
+	IngressClassName string `json:"ingressClassName,omitempty"`
+	// IngressAnnotations are added to the ingress of the SSO proxy
+	IngressAnnotations map[string]string `json:"ingressAnnotations,omitempty"`
 	// Docker image for oauth2_proxy
 	ProxyImage string `json:"proxyImage,omitempty"`
 	// Docker image tag for oauth2_proxy
@@ -44,14 +52,58 @@ type SSOSpec struct {
 	ForwardToken bool `json:"forwardToken,omitempty"`
 	// CookieSpec cookie specifications
 	CookieSpec CookieSpec `json:"cookieSpec,omitempty"`
+	// AllowedEmailDomains restricts the access to users with an email in these domains, any domain is allowed when empty
+	AllowedEmailDomains []string `json:"allowedEmailDomains,omitempty"`
+	// AllowedGroups restricts the access to the members of these dex groups
+	AllowedGroups []string `json:"allowedGroups,omitempty"`
+	// AllowedUsers are the emails of the users allowed in addition to the allowed email domains
+	AllowedUsers []string `json:"allowedUsers,omitempty"`
 	// URLTemplate to use in the exposecontroller configMap
 	URLTemplate string `json:"urlTemplate,omitempty"`
 	// SkipExposeService to avoid using exposecontroller to create ingress rule for proxy
 	SkipExposeService bool `json:"skipExposeService,omitempty"`
+	// Exposer which publicly exposes the SSO proxy, defaults to the exposecontroller
+	Exposer ExposerType `json:"exposer,omitempty"`
+	// Gateway to which the HTTPRoute of the SSO proxy is attached when exposed with the Gateway API
+	Gateway *GatewayReference `json:"gateway,omitempty"`
 	// SSLInsecureSkipVerify allows the proxy container to connect with a OIDC using selft-sogned certs, this should be used for testing only
 	SSLInsecureSkipVerify bool `json:"sslInsecureSkipVerify,omitempty"`
 }
 
+// Upstream is a service protected by the SSO proxy, which receives the requests matching its path prefix
+type Upstream struct {
+	// Name of the upstream service
+	Service string `json:"service"`
+	// Port of the upstream service by name or number, the first port of the service is used when empty
+	Port intstr.IntOrString `json:"port,omitempty"`
+	// Path prefix routed to the upstream service, defaults to "/"
+	Path string `json:"path,omitempty"`
+}
+
+// ExposerType defines how the SSO proxy is publicly exposed
+type ExposerType string
+
+const (
+	// ExposerExposeController exposes the SSO proxy with an ingress created by an exposecontroller job
+	ExposerExposeController ExposerType = "exposecontroller"
+	// ExposerIngress exposes the SSO proxy with a networking.k8s.io/v1 ingress owned by the SSO
+	ExposerIngress ExposerType = "ingress"
+	// ExposerHTTPRoute exposes the SSO proxy with a Gateway API HTTPRoute owned by the SSO
+	ExposerHTTPRoute ExposerType = "httproute"
+	// ExposerRoute exposes the SSO proxy with an OpenShift route owned by the SSO
+	ExposerRoute ExposerType = "route"
+)
+
+// GatewayReference identifies a Gateway API gateway
+type GatewayReference struct {
+	// Name of the gateway
+	Name string `json:"name"`
+	// Namespace of the gateway, defaults to the namespace of the SSO
+	Namespace string `json:"namespace,omitempty"`
+	// SectionName is the listener of the gateway to which the route is attached
+	SectionName string `json:"sectionName,omitempty"`
+}
+
 // CookieSpec is the specification of a cookie for a Single Sign-On resource
 type CookieSpec struct {
 	// Cookie name
@@ -64,6 +116,8 @@ type CookieSpec struct {
 	Secure bool `json:"secure,omitempty"`
 	// Cookie is not readable from JavaScript
 	HTTPOnly bool `json:"httpOnly,omitempty"`
+	// RotationInterval after which the secret which signs the cookie is rotated, the secret is not rotated when empty
+	RotationInterval string `json:"rotationInterval,omitempty"`
 }
 
 // SSOStatus is the status of an Single Sign-On resource
@@ -72,6 +126,69 @@ type SSOStatus struct {
 	ClientID string `json:"clientId,omitempty" protobuf:"bytes,2,opt,name=clientId"`
 	// Initialized indicated if the SSO was configured in dex and oauth2_proxy
 	Initialized bool `json:"initialized,omitempty" protobuf:"bytes,2,opt,name=initialized"`
+	// ObservedGeneration is the most recent generation of the SSO spec applied to the oauth2_proxy resources
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" protobuf:"varint,3,opt,name=observedGeneration"`
+	// Phase is a high level summary of where the SSO is in its lifecycle
+	Phase SSOPhase `json:"phase,omitempty" protobuf:"bytes,4,opt,name=phase,casttype=SSOPhase"`
+	// Conditions are the latest available observations of the SSO state
+	Conditions []SSOCondition `json:"conditions,omitempty" protobuf:"bytes,5,rep,name=conditions"`
+	// URLs under which the SSO proxy is publicly exposed
+	URLs []string `json:"urls,omitempty" protobuf:"bytes,6,rep,name=urls"`
+	// RedirectURIs registered in dex for the OIDC client
+	RedirectURIs []string `json:"redirectUris,omitempty" protobuf:"bytes,7,rep,name=redirectUris"`
+	// CookieSecretRotated is the last time the secret which signs the cookie was generated
+	CookieSecretRotated *metav1.Time `json:"cookieSecretRotated,omitempty" protobuf:"bytes,8,opt,name=cookieSecretRotated"`
+	// PendingClientID is the OIDC client which replaces the current client while its secret is rotated
+	PendingClientID string `json:"pendingClientId,omitempty" protobuf:"bytes,9,opt,name=pendingClientId"`
+	// ClientSecretRotated is the last time the secret of the OIDC client was rotated
+	ClientSecretRotated *metav1.Time `json:"clientSecretRotated,omitempty" protobuf:"bytes,10,opt,name=clientSecretRotated"`
+}
+
+// SSOPhase is a label for the lifecycle phase of a Single Sign-On resource
+type SSOPhase string
+
+const (
+	// SSOPhaseInitializing means that the OIDC client and the oauth2_proxy are being created
+	SSOPhaseInitializing SSOPhase = "Initializing"
+	// SSOPhaseReady means that the oauth2_proxy is deployed, exposed and registered in dex
+	SSOPhaseReady SSOPhase = "Ready"
+	// SSOPhaseFailed means that the last initialization or reconciliation failed
+	SSOPhaseFailed SSOPhase = "Failed"
+	// SSOPhaseDeleting means that the oauth2_proxy ingress and the OIDC client are being cleaned up
+	SSOPhaseDeleting SSOPhase = "Deleting"
+)
+
+// SSOConditionType is a valid value for SSOCondition.Type
+type SSOConditionType string
+
+const (
+	// SSODexClientReady means the OIDC client is registered in dex
+	SSODexClientReady SSOConditionType = "DexClientReady"
+	// SSOProxyDeployed means the oauth2_proxy secret, deployment and service are created
+	SSOProxyDeployed SSOConditionType = "ProxyDeployed"
+	// SSOExposed means the oauth2_proxy service is publicly exposed
+	SSOExposed SSOConditionType = "Exposed"
+	// SSORedirectURIsSynced means the redirect URIs of the OIDC client match the public URLs of the proxy
+	SSORedirectURIsSynced SSOConditionType = "RedirectURIsSynced"
+	// SSOReady means all the other conditions are satisfied
+	SSOReady SSOConditionType = "Ready"
+)
+
+// SSOCondition describes the state of a Single Sign-On resource at a certain point
+type SSOCondition struct {
+	// Type of SSO condition
+	Type SSOConditionType `json:"type" protobuf:"bytes,1,opt,name=type,casttype=SSOConditionType"`
+	// Status of the condition, one of True, False, Unknown
+	Status v1.ConditionStatus `json:"status" protobuf:"bytes,2,opt,name=status,casttype=k8s.io/api/core/v1.ConditionStatus"`
+	// Last time the condition transitioned from one status to another
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty" protobuf:"bytes,3,opt,name=lastTransitionTime"`
+	// Unique, one-word, CamelCase reason for the condition's last transition
+	// +optional
+	Reason string `json:"reason,omitempty" protobuf:"bytes,4,opt,name=reason"`
+	// Human-readable message indicating details about last transition
+	// +optional
+	Message string `json:"message,omitempty" protobuf:"bytes,5,opt,name=message"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
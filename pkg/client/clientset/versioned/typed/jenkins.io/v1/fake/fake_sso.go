@@ -3,6 +3,8 @@
 package fake
 
 import (
+	"context"
+
 	jenkinsiov1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	labels "k8s.io/apimachinery/pkg/labels"
@@ -23,7 +25,7 @@ var ssosResource = schema.GroupVersionResource{Group: "jenkins.io", Version: "v1
 var ssosKind = schema.GroupVersionKind{Group: "jenkins.io", Version: "v1", Kind: "SSO"}
 
 // Get takes name of the sSO, and returns the corresponding sSO object, and an error if there is any.
-func (c *FakeSSOs) Get(name string, options v1.GetOptions) (result *jenkinsiov1.SSO, err error) {
+func (c *FakeSSOs) Get(ctx context.Context, name string, options v1.GetOptions) (result *jenkinsiov1.SSO, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewGetAction(ssosResource, c.ns, name), &jenkinsiov1.SSO{})
 
@@ -34,7 +36,7 @@ func (c *FakeSSOs) Get(name string, options v1.GetOptions) (result *jenkinsiov1.
 }
 
 // List takes label and field selectors, and returns the list of SSOs that match those selectors.
-func (c *FakeSSOs) List(opts v1.ListOptions) (result *jenkinsiov1.SSOList, err error) {
+func (c *FakeSSOs) List(ctx context.Context, opts v1.ListOptions) (result *jenkinsiov1.SSOList, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewListAction(ssosResource, ssosKind, c.ns, opts), &jenkinsiov1.SSOList{})
 
@@ -56,14 +58,14 @@ func (c *FakeSSOs) List(opts v1.ListOptions) (result *jenkinsiov1.SSOList, err e
 }
 
 // Watch returns a watch.Interface that watches the requested sSOs.
-func (c *FakeSSOs) Watch(opts v1.ListOptions) (watch.Interface, error) {
+func (c *FakeSSOs) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
 	return c.Fake.
 		InvokesWatch(testing.NewWatchAction(ssosResource, c.ns, opts))
 
 }
 
 // Create takes the representation of a sSO and creates it.  Returns the server's representation of the sSO, and an error, if there is any.
-func (c *FakeSSOs) Create(sSO *jenkinsiov1.SSO) (result *jenkinsiov1.SSO, err error) {
+func (c *FakeSSOs) Create(ctx context.Context, sSO *jenkinsiov1.SSO, opts v1.CreateOptions) (result *jenkinsiov1.SSO, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewCreateAction(ssosResource, c.ns, sSO), &jenkinsiov1.SSO{})
 
@@ -74,7 +76,7 @@ func (c *FakeSSOs) Create(sSO *jenkinsiov1.SSO) (result *jenkinsiov1.SSO, err er
 }
 
 // Update takes the representation of a sSO and updates it. Returns the server's representation of the sSO, and an error, if there is any.
-func (c *FakeSSOs) Update(sSO *jenkinsiov1.SSO) (result *jenkinsiov1.SSO, err error) {
+func (c *FakeSSOs) Update(ctx context.Context, sSO *jenkinsiov1.SSO, opts v1.UpdateOptions) (result *jenkinsiov1.SSO, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewUpdateAction(ssosResource, c.ns, sSO), &jenkinsiov1.SSO{})
 
@@ -84,26 +86,38 @@ func (c *FakeSSOs) Update(sSO *jenkinsiov1.SSO) (result *jenkinsiov1.SSO, err er
 	return obj.(*jenkinsiov1.SSO), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeSSOs) UpdateStatus(ctx context.Context, sSO *jenkinsiov1.SSO, opts v1.UpdateOptions) (*jenkinsiov1.SSO, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(ssosResource, "status", c.ns, sSO), &jenkinsiov1.SSO{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*jenkinsiov1.SSO), err
+}
+
 // Delete takes name of the sSO and deletes it. Returns an error if one occurs.
-func (c *FakeSSOs) Delete(name string, options *v1.DeleteOptions) error {
+func (c *FakeSSOs) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewDeleteAction(ssosResource, c.ns, name), &jenkinsiov1.SSO{})
+		Invokes(testing.NewDeleteActionWithOptions(ssosResource, c.ns, name, opts), &jenkinsiov1.SSO{})
 
 	return err
 }
 
 // DeleteCollection deletes a collection of objects.
-func (c *FakeSSOs) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
-	action := testing.NewDeleteCollectionAction(ssosResource, c.ns, listOptions)
+func (c *FakeSSOs) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(ssosResource, c.ns, listOpts)
 
 	_, err := c.Fake.Invokes(action, &jenkinsiov1.SSOList{})
 	return err
 }
 
 // Patch applies the patch and returns the patched sSO.
-func (c *FakeSSOs) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *jenkinsiov1.SSO, err error) {
+func (c *FakeSSOs) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *jenkinsiov1.SSO, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewPatchSubresourceAction(ssosResource, c.ns, name, data, subresources...), &jenkinsiov1.SSO{})
+		Invokes(testing.NewPatchSubresourceAction(ssosResource, c.ns, name, pt, data, subresources...), &jenkinsiov1.SSO{})
 
 	if obj == nil {
 		return nil, err
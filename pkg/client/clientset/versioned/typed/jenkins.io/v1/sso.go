@@ -3,6 +3,9 @@
 package v1
 
 import (
+	"context"
+	"time"
+
 	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
 	scheme "github.com/jenkins-x/sso-operator/pkg/client/clientset/versioned/scheme"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,14 +22,15 @@ type SSOsGetter interface {
 
 // SSOInterface has methods to work with SSO resources.
 type SSOInterface interface {
-	Create(*v1.SSO) (*v1.SSO, error)
-	Update(*v1.SSO) (*v1.SSO, error)
-	Delete(name string, options *metav1.DeleteOptions) error
-	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
-	Get(name string, options metav1.GetOptions) (*v1.SSO, error)
-	List(opts metav1.ListOptions) (*v1.SSOList, error)
-	Watch(opts metav1.ListOptions) (watch.Interface, error)
-	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.SSO, err error)
+	Create(ctx context.Context, sSO *v1.SSO, opts metav1.CreateOptions) (*v1.SSO, error)
+	Update(ctx context.Context, sSO *v1.SSO, opts metav1.UpdateOptions) (*v1.SSO, error)
+	UpdateStatus(ctx context.Context, sSO *v1.SSO, opts metav1.UpdateOptions) (*v1.SSO, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.SSO, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.SSOList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.SSO, err error)
 	SSOExpansion
 }
 
@@ -45,97 +49,131 @@ func newSSOs(c *JenkinsV1Client, namespace string) *sSOs {
 }
 
 // Get takes name of the sSO, and returns the corresponding sSO object, and an error if there is any.
-func (c *sSOs) Get(name string, options metav1.GetOptions) (result *v1.SSO, err error) {
+func (c *sSOs) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.SSO, err error) {
 	result = &v1.SSO{}
 	err = c.client.Get().
 		Namespace(c.ns).
 		Resource("ssos").
 		Name(name).
 		VersionedParams(&options, scheme.ParameterCodec).
-		Do().
+		Do(ctx).
 		Into(result)
 	return
 }
 
 // List takes label and field selectors, and returns the list of SSOs that match those selectors.
-func (c *sSOs) List(opts metav1.ListOptions) (result *v1.SSOList, err error) {
+func (c *sSOs) List(ctx context.Context, opts metav1.ListOptions) (result *v1.SSOList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
 	result = &v1.SSOList{}
 	err = c.client.Get().
 		Namespace(c.ns).
 		Resource("ssos").
 		VersionedParams(&opts, scheme.ParameterCodec).
-		Do().
+		Timeout(timeout).
+		Do(ctx).
 		Into(result)
 	return
 }
 
 // Watch returns a watch.Interface that watches the requested sSOs.
-func (c *sSOs) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+func (c *sSOs) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
 	opts.Watch = true
 	return c.client.Get().
 		Namespace(c.ns).
 		Resource("ssos").
 		VersionedParams(&opts, scheme.ParameterCodec).
-		Watch()
+		Timeout(timeout).
+		Watch(ctx)
 }
 
 // Create takes the representation of a sSO and creates it.  Returns the server's representation of the sSO, and an error, if there is any.
-func (c *sSOs) Create(sSO *v1.SSO) (result *v1.SSO, err error) {
+func (c *sSOs) Create(ctx context.Context, sSO *v1.SSO, opts metav1.CreateOptions) (result *v1.SSO, err error) {
 	result = &v1.SSO{}
 	err = c.client.Post().
 		Namespace(c.ns).
 		Resource("ssos").
+		VersionedParams(&opts, scheme.ParameterCodec).
 		Body(sSO).
-		Do().
+		Do(ctx).
 		Into(result)
 	return
 }
 
 // Update takes the representation of a sSO and updates it. Returns the server's representation of the sSO, and an error, if there is any.
-func (c *sSOs) Update(sSO *v1.SSO) (result *v1.SSO, err error) {
+func (c *sSOs) Update(ctx context.Context, sSO *v1.SSO, opts metav1.UpdateOptions) (result *v1.SSO, err error) {
+	result = &v1.SSO{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("ssos").
+		Name(sSO.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(sSO).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *sSOs) UpdateStatus(ctx context.Context, sSO *v1.SSO, opts metav1.UpdateOptions) (result *v1.SSO, err error) {
 	result = &v1.SSO{}
 	err = c.client.Put().
 		Namespace(c.ns).
 		Resource("ssos").
 		Name(sSO.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
 		Body(sSO).
-		Do().
+		Do(ctx).
 		Into(result)
 	return
 }
 
 // Delete takes name of the sSO and deletes it. Returns an error if one occurs.
-func (c *sSOs) Delete(name string, options *metav1.DeleteOptions) error {
+func (c *sSOs) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
 	return c.client.Delete().
 		Namespace(c.ns).
 		Resource("ssos").
 		Name(name).
-		Body(options).
-		Do().
+		Body(&opts).
+		Do(ctx).
 		Error()
 }
 
 // DeleteCollection deletes a collection of objects.
-func (c *sSOs) DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+func (c *sSOs) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
 	return c.client.Delete().
 		Namespace(c.ns).
 		Resource("ssos").
-		VersionedParams(&listOptions, scheme.ParameterCodec).
-		Body(options).
-		Do().
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
 		Error()
 }
 
 // Patch applies the patch and returns the patched sSO.
-func (c *sSOs) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.SSO, err error) {
+func (c *sSOs) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.SSO, err error) {
 	result = &v1.SSO{}
 	err = c.client.Patch(pt).
 		Namespace(c.ns).
 		Resource("ssos").
-		SubResource(subresources...).
 		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
 		Body(data).
-		Do().
+		Do(ctx).
 		Into(result)
 	return
 }
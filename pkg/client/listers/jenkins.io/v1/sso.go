@@ -10,8 +10,10 @@ import (
 )
 
 // SSOLister helps list SSOs.
+// All objects returned here must be treated as read-only.
 type SSOLister interface {
 	// List lists all SSOs in the indexer.
+	// Objects returned here must be treated as read-only.
 	List(selector labels.Selector) (ret []*v1.SSO, err error)
 	// SSOs returns an object that can list and get SSOs.
 	SSOs(namespace string) SSONamespaceLister
@@ -42,10 +44,13 @@ func (s *sSOLister) SSOs(namespace string) SSONamespaceLister {
 }
 
 // SSONamespaceLister helps list and get SSOs.
+// All objects returned here must be treated as read-only.
 type SSONamespaceLister interface {
 	// List lists all SSOs in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
 	List(selector labels.Selector) (ret []*v1.SSO, err error)
 	// Get retrieves the SSO from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
 	Get(name string) (*v1.SSO, error)
 	SSONamespaceListerExpansion
 }
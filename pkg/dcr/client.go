@@ -0,0 +1,297 @@
+package dcr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jenkins-x/sso-operator/pkg/idp"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Options keeps the configuration of the Dynamic Client Registration client
+type Options struct {
+	// RegistrationURL is the client registration endpoint of the identity provider
+	RegistrationURL string
+	// InitialAccessToken authorizes the registrations when the identity provider requires it
+	InitialAccessToken string
+	// Namespace where the registrations of the clients are stored
+	Namespace string
+	// Timeout of the requests to the identity provider
+	Timeout time.Duration
+}
+
+// Client registers the OIDC clients in an identity provider which implements the OAuth 2.0 Dynamic Client
+// Registration protocol (RFC 7591) and its management protocol (RFC 7592). The identity provider generates the
+// client ID and secret, they are stored together with the registration access token indexed by the ID which
+// the operator chose for the client.
+type Client struct {
+	registrationURL    string
+	initialAccessToken string
+	http               *http.Client
+	store              registrationStore
+}
+
+var _ idp.Registrar = &Client{}
+
+// clientMetadata is the client information exchanged with the registration endpoints
+type clientMetadata struct {
+	ClientID                string   `json:"client_id,omitempty"`
+	ClientSecret            string   `json:"client_secret,omitempty"`
+	RegistrationAccessToken string   `json:"registration_access_token,omitempty"`
+	RegistrationClientURI   string   `json:"registration_client_uri,omitempty"`
+	RedirectURIs            []string `json:"redirect_uris"`
+	ClientName              string   `json:"client_name,omitempty"`
+	LogoURI                 string   `json:"logo_uri,omitempty"`
+	TokenEndpointAuthMethod string   `json:"token_endpoint_auth_method,omitempty"`
+	GrantTypes              []string `json:"grant_types,omitempty"`
+	ResponseTypes           []string `json:"response_types,omitempty"`
+}
+
+// responseError is a failed request to the identity provider
+type responseError struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *responseError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("identity provider responded with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("identity provider responded with status %d: %s %s", e.StatusCode, e.Code, e.Description)
+}
+
+// isGone checks if a client management request failed because the client does not exist anymore, the
+// identity providers answer with 401 when the registration access token is not valid for any client
+func isGone(err error) bool {
+	respErr, ok := errors.Cause(err).(*responseError)
+	return ok && (respErr.StatusCode == http.StatusUnauthorized || respErr.StatusCode == http.StatusNotFound)
+}
+
+// NewClient creates a new Dynamic Client Registration client
+func NewClient(opts *Options) (*Client, error) {
+	if opts.RegistrationURL == "" {
+		return nil, errors.New("client registration URL is empty")
+	}
+	_, err := url.ParseRequestURI(opts.RegistrationURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing the client registration URL %q", opts.RegistrationURL)
+	}
+	return &Client{
+		registrationURL:    opts.RegistrationURL,
+		initialAccessToken: opts.InitialAccessToken,
+		http:               &http.Client{Timeout: opts.Timeout},
+		store:              &secretStore{namespace: opts.Namespace},
+	}, nil
+}
+
+// CreateClient registers a new OIDC client. A client already registered with the same ID is adopted, which
+// allows the creation to be safely retried, unless it was removed from the identity provider.
+func (c *Client) CreateClient(ctx context.Context, client *idp.Client) (*idp.Client, error) {
+	reg, err := c.store.get(client.ID)
+	if err != nil {
+		return nil, err
+	}
+	if reg != nil {
+		metadata := &clientMetadata{}
+		err = c.do(ctx, http.MethodGet, reg.RegistrationClientURI, reg.RegistrationAccessToken, nil, metadata)
+		if err == nil {
+			return toClient(client.ID, reg, metadata), nil
+		}
+		if !isGone(err) {
+			return nil, errors.Wrapf(err, "reading the registered client with id %q", client.ID)
+		}
+		logrus.Infof("OIDC client '%s' was removed from the identity provider, registering it again", client.ID)
+	}
+
+	metadata := &clientMetadata{}
+	err = c.do(ctx, http.MethodPost, c.registrationURL, c.initialAccessToken, toMetadata(client), metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to register the OIDC client")
+	}
+	if metadata.ClientID == "" || metadata.RegistrationClientURI == "" || metadata.RegistrationAccessToken == "" {
+		return nil, errors.New("the identity provider did not return a client ID and the registration " +
+			"access token of the client, which are required to manage the client")
+	}
+	reg = &registration{
+		ClientID:                metadata.ClientID,
+		ClientSecret:            metadata.ClientSecret,
+		RegistrationClientURI:   metadata.RegistrationClientURI,
+		RegistrationAccessToken: metadata.RegistrationAccessToken,
+	}
+	err = c.store.put(client.ID, reg)
+	if err != nil {
+		// The client cannot be managed without its registration access token
+		delErr := c.do(ctx, http.MethodDelete, reg.RegistrationClientURI, reg.RegistrationAccessToken, nil, nil)
+		if delErr != nil {
+			logrus.Warnf("failed to delete the OIDC client '%s' which could not be stored: %v", metadata.ClientID, delErr)
+		}
+		return nil, errors.Wrapf(err, "storing the registration of the client with id %q", client.ID)
+	}
+	return toClient(client.ID, reg, metadata), nil
+}
+
+// UpdateClient updates an already registered OIDC client, the client secret is sent back in order to keep it
+func (c *Client) UpdateClient(ctx context.Context, client *idp.Client) error {
+	reg, err := c.registration(client.ID)
+	if err != nil {
+		return err
+	}
+	req := toMetadata(client)
+	req.ClientID = reg.ClientID
+	req.ClientSecret = reg.ClientSecret
+	metadata := &clientMetadata{}
+	err = c.do(ctx, http.MethodPut, reg.RegistrationClientURI, reg.RegistrationAccessToken, req, metadata)
+	if isGone(err) {
+		return errors.Wrapf(idp.ErrClientNotFound, "update did not find the client with id %q", client.ID)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to update the client with id %q", client.ID)
+	}
+
+	// The identity provider may rotate the registration access token on every update
+	if metadata.RegistrationAccessToken != "" && metadata.RegistrationAccessToken != reg.RegistrationAccessToken {
+		reg.RegistrationAccessToken = metadata.RegistrationAccessToken
+		if metadata.RegistrationClientURI != "" {
+			reg.RegistrationClientURI = metadata.RegistrationClientURI
+		}
+		err = c.store.put(client.ID, reg)
+		if err != nil {
+			return errors.Wrapf(err, "storing the registration of the client with id %q", client.ID)
+		}
+	}
+	return nil
+}
+
+// DeleteClient deletes the client with the given ID from the identity provider
+func (c *Client) DeleteClient(ctx context.Context, id string) error {
+	reg, err := c.registration(id)
+	if err != nil {
+		return err
+	}
+	err = c.do(ctx, http.MethodDelete, reg.RegistrationClientURI, reg.RegistrationAccessToken, nil, nil)
+	gone := isGone(err)
+	if err != nil && !gone {
+		return errors.Wrapf(err, "failed to delete the client with id %q", id)
+	}
+	err = c.store.delete(id)
+	if err != nil {
+		return errors.Wrapf(err, "removing the registration of the client with id %q", id)
+	}
+	if gone {
+		return errors.Wrapf(idp.ErrClientNotFound, "delete did not find the client with id %q", id)
+	}
+	return nil
+}
+
+// GetClient reads the client with the given ID from the identity provider
+func (c *Client) GetClient(ctx context.Context, id string) (*idp.Client, error) {
+	reg, err := c.registration(id)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &clientMetadata{}
+	err = c.do(ctx, http.MethodGet, reg.RegistrationClientURI, reg.RegistrationAccessToken, nil, metadata)
+	if isGone(err) {
+		return nil, errors.Wrapf(idp.ErrClientNotFound, "did not find the client with id %q", id)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the client with id %q", id)
+	}
+	return toClient(id, reg, metadata), nil
+}
+
+// registration returns the stored registration of the client, ErrClientNotFound is returned when the client
+// was never registered
+func (c *Client) registration(id string) (*registration, error) {
+	reg, err := c.store.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if reg == nil {
+		return nil, errors.Wrapf(idp.ErrClientNotFound, "no registration of the client with id %q", id)
+	}
+	return reg, nil
+}
+
+// do sends a JSON request to the identity provider and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method string, url string, token string, in interface{}, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return errors.Wrap(err, "marshaling the client metadata")
+		}
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "creating the request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "reading the response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respErr := &responseError{}
+		_ = json.Unmarshal(data, respErr)
+		respErr.StatusCode = resp.StatusCode
+		return respErr
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return errors.Wrap(json.Unmarshal(data, out), "unmarshaling the client metadata")
+}
+
+func toMetadata(client *idp.Client) *clientMetadata {
+	authMethod := "client_secret_basic"
+	if client.Public {
+		authMethod = "none"
+	}
+	return &clientMetadata{
+		RedirectURIs:            client.RedirectURIs,
+		ClientName:              client.Name,
+		LogoURI:                 client.LogoURL,
+		TokenEndpointAuthMethod: authMethod,
+		GrantTypes:              []string{"authorization_code", "refresh_token"},
+		ResponseTypes:           []string{"code"},
+	}
+}
+
+func toClient(id string, reg *registration, metadata *clientMetadata) *idp.Client {
+	secret := metadata.ClientSecret
+	if secret == "" {
+		secret = reg.ClientSecret
+	}
+	return &idp.Client{
+		ID:           id,
+		IssuedID:     reg.ClientID,
+		Secret:       secret,
+		RedirectURIs: metadata.RedirectURIs,
+		Public:       metadata.TokenEndpointAuthMethod == "none",
+		Name:         metadata.ClientName,
+		LogoURL:      metadata.LogoURI,
+	}
+}
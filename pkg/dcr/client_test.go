@@ -0,0 +1,185 @@
+package dcr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/sso-operator/pkg/idp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore map[string]*registration
+
+func (s memoryStore) get(id string) (*registration, error) {
+	reg, ok := s[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *reg
+	return &copied, nil
+}
+
+func (s memoryStore) put(id string, reg *registration) error {
+	copied := *reg
+	s[id] = &copied
+	return nil
+}
+
+func (s memoryStore) delete(id string) error {
+	delete(s, id)
+	return nil
+}
+
+// fakeProvider implements the registration endpoints of RFC 7591 and 7592
+type fakeProvider struct {
+	server    *httptest.Server
+	clients   map[string]*clientMetadata
+	tokens    map[string]string
+	sequence  int
+	registers int
+}
+
+func newFakeProvider() *fakeProvider {
+	p := &fakeProvider{clients: map[string]*clientMetadata{}, tokens: map[string]string{}}
+	p.server = httptest.NewServer(http.HandlerFunc(p.serve))
+	return p
+}
+
+func (p *fakeProvider) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/register" && r.Method == http.MethodPost {
+		if r.Header.Get("Authorization") != "Bearer initial" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		metadata := &clientMetadata{}
+		_ = json.NewDecoder(r.Body).Decode(metadata)
+		p.sequence++
+		p.registers++
+		metadata.ClientID = fmt.Sprintf("issued-%d", p.sequence)
+		metadata.ClientSecret = fmt.Sprintf("secret-%d", p.sequence)
+		metadata.RegistrationClientURI = p.server.URL + "/register/" + metadata.ClientID
+		metadata.RegistrationAccessToken = "token-" + metadata.ClientID
+		p.clients[metadata.ClientID] = metadata
+		p.tokens[metadata.ClientID] = metadata.RegistrationAccessToken
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(metadata)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/register/")
+	metadata, ok := p.clients[id]
+	if !ok || r.Header.Get("Authorization") != "Bearer "+p.tokens[id] {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		_ = json.NewEncoder(w).Encode(metadata)
+	case http.MethodPut:
+		updated := &clientMetadata{}
+		_ = json.NewDecoder(r.Body).Decode(updated)
+		updated.RegistrationClientURI = metadata.RegistrationClientURI
+		updated.RegistrationAccessToken = "rotated-" + id
+		p.tokens[id] = updated.RegistrationAccessToken
+		p.clients[id] = updated
+		_ = json.NewEncoder(w).Encode(updated)
+	case http.MethodDelete:
+		delete(p.clients, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newTestClient(p *fakeProvider) (*Client, memoryStore) {
+	store := memoryStore{}
+	return &Client{
+		registrationURL:    p.server.URL + "/register",
+		initialAccessToken: "initial",
+		http:               p.server.Client(),
+		store:              store,
+	}, store
+}
+
+func TestClientLifecycle(t *testing.T) {
+	p := newFakeProvider()
+	defer p.server.Close()
+	c, store := newTestClient(p)
+	ctx := context.Background()
+
+	created, err := c.CreateClient(ctx, &idp.Client{
+		ID:           "jx-test-123",
+		Secret:       "ignored",
+		RedirectURIs: []string{"https://fake/oauth2/callback"},
+		Name:         "test",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "jx-test-123", created.ID)
+	assert.Equal(t, "issued-1", created.IssuedID)
+	assert.Equal(t, "issued-1", created.OIDCClientID())
+	assert.Equal(t, "secret-1", created.Secret)
+	assert.Equal(t, "client_secret_basic", p.clients["issued-1"].TokenEndpointAuthMethod)
+
+	// A retried creation adopts the registered client
+	adopted, err := c.CreateClient(ctx, &idp.Client{ID: "jx-test-123", RedirectURIs: []string{"https://fake/oauth2/callback"}})
+	require.NoError(t, err)
+	assert.Equal(t, "issued-1", adopted.IssuedID)
+	assert.Equal(t, "secret-1", adopted.Secret)
+	assert.Equal(t, 1, p.registers)
+
+	created.RedirectURIs = []string{"https://test.example.com/oauth2/callback"}
+	err = c.UpdateClient(ctx, created)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://test.example.com/oauth2/callback"}, p.clients["issued-1"].RedirectURIs)
+	assert.Equal(t, "secret-1", p.clients["issued-1"].ClientSecret)
+	assert.Equal(t, "rotated-issued-1", store["jx-test-123"].RegistrationAccessToken)
+
+	read, err := c.GetClient(ctx, "jx-test-123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://test.example.com/oauth2/callback"}, read.RedirectURIs)
+
+	err = c.DeleteClient(ctx, "jx-test-123")
+	require.NoError(t, err)
+	assert.Empty(t, p.clients)
+	assert.Empty(t, store)
+
+	err = c.DeleteClient(ctx, "jx-test-123")
+	assert.True(t, idp.IsNotFound(err))
+	_, err = c.GetClient(ctx, "jx-test-123")
+	assert.True(t, idp.IsNotFound(err))
+}
+
+func TestCreateClientRemovedFromProvider(t *testing.T) {
+	p := newFakeProvider()
+	defer p.server.Close()
+	c, store := newTestClient(p)
+	ctx := context.Background()
+
+	_, err := c.CreateClient(ctx, &idp.Client{ID: "jx-test-123", RedirectURIs: []string{"https://fake/oauth2/callback"}})
+	require.NoError(t, err)
+	delete(p.clients, "issued-1")
+
+	created, err := c.CreateClient(ctx, &idp.Client{ID: "jx-test-123", RedirectURIs: []string{"https://fake/oauth2/callback"}})
+	require.NoError(t, err)
+	assert.Equal(t, "issued-2", created.IssuedID)
+	assert.Equal(t, "issued-2", store["jx-test-123"].ClientID)
+
+	err = c.UpdateClient(ctx, &idp.Client{ID: "unknown"})
+	assert.True(t, idp.IsNotFound(err))
+}
+
+func TestCreateClientRejected(t *testing.T) {
+	p := newFakeProvider()
+	defer p.server.Close()
+	c, store := newTestClient(p)
+	c.initialAccessToken = "wrong"
+
+	_, err := c.CreateClient(context.Background(), &idp.Client{ID: "jx-test-123"})
+	assert.EqualError(t, err, "failed to register the OIDC client: identity provider responded with status 401")
+	assert.False(t, idp.IsNotFound(err))
+	assert.Empty(t, store)
+}
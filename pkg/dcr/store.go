@@ -0,0 +1,112 @@
+package dcr
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const registrationsSecretName = "sso-operator-dcr-registrations"
+
+// registration keeps what the identity provider returned when the client was registered, the registration
+// access token is required to read, update and delete the client
+type registration struct {
+	ClientID                string `json:"clientId"`
+	ClientSecret            string `json:"clientSecret,omitempty"`
+	RegistrationClientURI   string `json:"registrationClientUri"`
+	RegistrationAccessToken string `json:"registrationAccessToken"`
+}
+
+// registrationStore keeps the registrations indexed by the ID chosen by the operator for the client
+type registrationStore interface {
+	get(id string) (*registration, error)
+	put(id string, reg *registration) error
+	delete(id string) error
+}
+
+// secretStore keeps the registrations in a secret since they contain the credentials of the clients
+type secretStore struct {
+	namespace string
+}
+
+func (s *secretStore) get(id string) (*registration, error) {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting k8s client")
+	}
+	secret, err := k8sClient.CoreV1().Secrets(s.namespace).Get(context.TODO(), registrationsSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the client registrations secret")
+	}
+	value, ok := secret.Data[id]
+	if !ok {
+		return nil, nil
+	}
+	reg := &registration{}
+	err = json.Unmarshal(value, reg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshaling the registration of client '%s'", id)
+	}
+	return reg, nil
+}
+
+func (s *secretStore) put(id string, reg *registration) error {
+	value, err := json.Marshal(reg)
+	if err != nil {
+		return errors.Wrap(err, "marshaling client registration")
+	}
+	return s.update(func(data map[string][]byte) {
+		data[id] = value
+	})
+}
+
+func (s *secretStore) delete(id string) error {
+	return s.update(func(data map[string][]byte) {
+		delete(data, id)
+	})
+}
+
+func (s *secretStore) update(change func(data map[string][]byte)) error {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+	secrets := k8sClient.CoreV1().Secrets(s.namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := secrets.Get(context.TODO(), registrationsSecretName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      registrationsSecretName,
+					Namespace: s.namespace,
+				},
+				Data: map[string][]byte{},
+				Type: corev1.SecretTypeOpaque,
+			}
+			change(secret.Data)
+			_, err = secrets.Create(context.TODO(), secret, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				return apierrors.NewConflict(corev1.Resource("secrets"), registrationsSecretName, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		change(secret.Data)
+		_, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+		return err
+	})
+}
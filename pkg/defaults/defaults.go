@@ -0,0 +1,63 @@
+package defaults
+
+import (
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+)
+
+// Defaults holds the operator-wide values of the SSO spec fields which are left empty
+type Defaults struct {
+	// OIDCIssuerURL URL of dex IdP
+	OIDCIssuerURL string
+	// Domain name under which the SSO services are exposed
+	Domain string
+	// CertIssuerName cert-manager issuer name
+	CertIssuerName string
+	// CertClusterIssuerName cert-manager cluster issuer name
+	CertClusterIssuerName string
+	// URLTemplate of the public host of the SSO services
+	URLTemplate string
+	// ProxyImage Docker image for oauth2_proxy
+	ProxyImage string
+	// ProxyImageTag Docker image tag for oauth2_proxy
+	ProxyImageTag string
+	// CookieExpire expiration time of the cookie
+	CookieExpire string
+	// CookieRefresh refresh time of the cookie
+	CookieRefresh string
+	// CookieSecure indicates if the cookie is only sent over HTTPS, only applied to a SSO without cookie spec
+	CookieSecure bool
+	// CookieHTTPOnly indicates if the cookie is not readable from JavaScript, only applied to a SSO without cookie spec
+	CookieHTTPOnly bool
+}
+
+// Apply fills the empty fields of the SSO spec with the defaults, and returns true when the spec was changed.
+// The cookie flags cannot be told apart from an explicit false, so they are only defaulted when the SSO has
+// no cookie spec at all.
+func (d *Defaults) Apply(sso *v1.SSO) bool {
+	spec := &sso.Spec
+	changed := false
+	setString := func(field *string, value string) {
+		if *field == "" && value != "" {
+			*field = value
+			changed = true
+		}
+	}
+
+	if spec.CookieSpec == (v1.CookieSpec{}) && (d.CookieSecure || d.CookieHTTPOnly) {
+		spec.CookieSpec.Secure = d.CookieSecure
+		spec.CookieSpec.HTTPOnly = d.CookieHTTPOnly
+		changed = true
+	}
+	setString(&spec.OIDCIssuerURL, d.OIDCIssuerURL)
+	setString(&spec.Domain, d.Domain)
+	if spec.CertIssuerName == "" && spec.CertClusterIssuerName == "" {
+		setString(&spec.CertIssuerName, d.CertIssuerName)
+		setString(&spec.CertClusterIssuerName, d.CertClusterIssuerName)
+	}
+	setString(&spec.URLTemplate, d.URLTemplate)
+	setString(&spec.ProxyImage, d.ProxyImage)
+	setString(&spec.ProxyImageTag, d.ProxyImageTag)
+	setString(&spec.CookieSpec.Expire, d.CookieExpire)
+	setString(&spec.CookieSpec.Refresh, d.CookieRefresh)
+	return changed
+}
@@ -0,0 +1,57 @@
+package defaults
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDefaults() *Defaults {
+	return &Defaults{
+		OIDCIssuerURL:  "https://dex.jx.example.com",
+		Domain:         "example.com",
+		CertIssuerName: "letsencrypt-prod",
+		ProxyImage:     "quay.io/pusher/oauth2_proxy",
+		ProxyImageTag:  "v3.2.0",
+		CookieExpire:   "168h",
+		CookieRefresh:  "60m",
+		CookieSecure:   true,
+		CookieHTTPOnly: true,
+	}
+}
+
+func TestApplyMinimalSSO(t *testing.T) {
+	sso := &v1.SSO{Spec: v1.SSOSpec{UpstreamService: "golang-http"}}
+
+	changed := testDefaults().Apply(sso)
+
+	assert.True(t, changed)
+	assert.Equal(t, "https://dex.jx.example.com", sso.Spec.OIDCIssuerURL)
+	assert.Equal(t, "example.com", sso.Spec.Domain)
+	assert.Equal(t, "letsencrypt-prod", sso.Spec.CertIssuerName)
+	assert.Equal(t, "quay.io/pusher/oauth2_proxy", sso.Spec.ProxyImage)
+	assert.Equal(t, "v3.2.0", sso.Spec.ProxyImageTag)
+	assert.Equal(t, v1.CookieSpec{Expire: "168h", Refresh: "60m", Secure: true, HTTPOnly: true}, sso.Spec.CookieSpec)
+}
+
+func TestApplyKeepsSetFields(t *testing.T) {
+	sso := &v1.SSO{Spec: v1.SSOSpec{
+		UpstreamService:       "golang-http",
+		OIDCIssuerURL:         "https://dex.other.com",
+		Domain:                "other.com",
+		CertClusterIssuerName: "letsencrypt-cluster",
+		ProxyImage:            "quay.io/pusher/oauth2_proxy",
+		ProxyImageTag:         "v4.0.0",
+		CookieSpec:            v1.CookieSpec{Expire: "1h", Refresh: "10m"},
+	}}
+
+	changed := testDefaults().Apply(sso)
+
+	assert.False(t, changed)
+	assert.Equal(t, "https://dex.other.com", sso.Spec.OIDCIssuerURL)
+	assert.Equal(t, "", sso.Spec.CertIssuerName, "an issuer should not be defaulted next to a cluster issuer")
+	assert.Equal(t, "v4.0.0", sso.Spec.ProxyImageTag)
+	assert.False(t, sso.Spec.CookieSpec.Secure, "an explicit cookie spec should be kept")
+	assert.False(t, sso.Spec.CookieSpec.HTTPOnly)
+}
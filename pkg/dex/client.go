@@ -2,15 +2,18 @@ package dex
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"fmt"
-	"io/ioutil"
+	"strings"
+	"time"
 
-	"github.com/dexidp/dex/api"
+	"github.com/dexidp/dex/api/v2"
+	"github.com/jenkins-x/sso-operator/pkg/idp"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 // Options keeps some configuration options for Dex client
@@ -23,39 +26,67 @@ type Options struct {
 	ClientKey string
 	// ClientCA self signed CA certificate for gRPC TLS connection
 	ClientCA string
+	// Timeout of every attempt of a gRPC call, defaults to 10 seconds
+	Timeout time.Duration
+	// Retries is the number of times a call is retried while dex is unavailable, none by default
+	Retries int
+	// KeepaliveTime is the interval of the pings which check that the connection is alive while a call is in
+	// flight, defaults to MinKeepaliveTime
+	KeepaliveTime time.Duration
 }
 
+// MinKeepaliveTime is the shortest keepalive interval accepted by dex, which keeps the default enforcement policy
+// of the gRPC servers and closes the connections pinged more often or pinged while no call is in flight
+const MinKeepaliveTime = 5 * time.Minute
+
+// MinAPIVersion is the oldest version of the dex gRPC API accepted by the operator. The client creation, update and
+// deletion calls are in the initial version of the API. The GetClient and ListClients calls are newer, a dex which
+// does not serve them answers them as unimplemented and GetClient falls back to a lookup with an update.
+const MinAPIVersion = 0
+
+const (
+	defaultTimeout = 10 * time.Second
+	retryDelay     = 500 * time.Millisecond
+)
+
 // Client represent a client wrapper for Dex
 type Client struct {
 	dex api.DexClient
 }
 
+var _ idp.Registrar = &Client{}
+
 // NewClient creates a new Dex client
 func NewClient(opts *Options) (*Client, error) {
-	certPool := x509.NewCertPool()
-	caCert, err := ioutil.ReadFile(opts.ClientCA) // #nosec
+	creds, err := newReloadingCredentials(opts.ClientCrt, opts.ClientKey, opts.ClientCA)
 	if err != nil {
-		return nil, errors.Wrapf(err, "reading the public CA cert from %q", opts.ClientCA)
-	}
-	appended := certPool.AppendCertsFromPEM(caCert)
-	if !appended {
-		return nil, errors.New("failed to append the CA cert to the certs pool")
+		return nil, errors.Wrap(err, "loading the gRPC client certificates")
 	}
 
-	clientCert, err := tls.LoadX509KeyPair(opts.ClientCrt, opts.ClientKey)
-	if err != nil {
-		return nil, errors.Wrapf(err, "loading the client cert %q and private key %q",
-			opts.ClientCrt, opts.ClientKey)
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
 	}
-
-	clientTLSConfig := &tls.Config{
-		RootCAs:      certPool,
-		Certificates: []tls.Certificate{clientCert},
-		MinVersion:   tls.VersionTLS12,
+	keepaliveTime := opts.KeepaliveTime
+	if keepaliveTime <= 0 {
+		keepaliveTime = MinKeepaliveTime
 	}
-	creds := credentials.NewTLS(clientTLSConfig)
-
-	conn, err := grpc.Dial(opts.HostAndPort, grpc.WithTransportCredentials(creds))
+	if keepaliveTime < MinKeepaliveTime {
+		return nil, errors.Errorf("the gRPC keepalive time %s is shorter than the minimum %s accepted by dex",
+			keepaliveTime, MinKeepaliveTime)
+	}
+	backoff := wait.Backoff{Duration: retryDelay, Factor: 2, Steps: opts.Retries + 1}
+
+	// The connection is established in the background and re-established by gRPC when it is lost. The keepalive
+	// pings are only sent while a call is in flight, dex closes the connections pinged without any call.
+	conn, err := grpc.Dial(opts.HostAndPort,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(retryUnavailable(timeout, backoff), observeRequest),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                keepaliveTime,
+			Timeout:             timeout,
+			PermitWithoutStream: false,
+		}))
 	if err != nil {
 		return nil, errors.Wrapf(err, "opening the gRPC connection with server %q", opts.HostAndPort)
 	}
@@ -64,49 +95,47 @@ func NewClient(opts *Options) (*Client, error) {
 	}, nil
 }
 
-// CreateClient a new OIDC client in Dex
-func (c *Client) CreateClient(ctx context.Context, redirectUris []string, trustedPeers []string,
-	public bool, name string, logoURL string) (*api.Client, error) {
+// CreateClient a new OIDC client in Dex with the given ID and secret. An already existing client with
+// the same ID is adopted, which allows the creation to be safely retried.
+func (c *Client) CreateClient(ctx context.Context, client *idp.Client) (*idp.Client, error) {
 	req := &api.CreateClientReq{
 		Client: &api.Client{
-			RedirectUris: redirectUris,
-			TrustedPeers: trustedPeers,
-			Public:       public,
-			Name:         name,
-			LogoUrl:      logoURL,
+			Id:           client.ID,
+			Secret:       client.Secret,
+			RedirectUris: client.RedirectURIs,
+			TrustedPeers: client.TrustedPeers,
+			Public:       client.Public,
+			Name:         client.Name,
+			LogoUrl:      client.LogoURL,
 		},
 	}
-
 	res, err := c.dex.CreateClient(ctx, req)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create the OIDC client")
 	}
-
 	if res.AlreadyExists {
-		return nil, errors.Wrapf(err, "client %q already exists", res.Client.Id)
+		return fromAPIClient(req.Client), nil
 	}
-
-	return res.Client, nil
+	return fromAPIClient(res.Client), nil
 }
 
 // UpdateClient updates an already registered OIDC client
-func (c *Client) UpdateClient(ctx context.Context, clientID string, redirectUris []string,
-	trustedPeers []string, public bool, name string, logoURL string) error {
+func (c *Client) UpdateClient(ctx context.Context, client *idp.Client) error {
 	req := &api.UpdateClientReq{
-		Id:           clientID,
-		RedirectUris: redirectUris,
-		TrustedPeers: trustedPeers,
-		Name:         name,
-		LogoUrl:      logoURL,
+		Id:           client.ID,
+		RedirectUris: client.RedirectURIs,
+		TrustedPeers: client.TrustedPeers,
+		Name:         client.Name,
+		LogoUrl:      client.LogoURL,
 	}
 
 	res, err := c.dex.UpdateClient(ctx, req)
 	if err != nil {
-		return errors.Wrapf(err, "failed to update the client with id %q", clientID)
+		return errors.Wrapf(err, "failed to update the client with id %q", client.ID)
 	}
 
 	if res.NotFound {
-		return fmt.Errorf("update did not find the client with id %q", clientID)
+		return errors.Wrapf(idp.ErrClientNotFound, "update did not find the client with id %q", client.ID)
 	}
 	return nil
 }
@@ -121,7 +150,101 @@ func (c *Client) DeleteClient(ctx context.Context, id string) error {
 		return errors.Wrapf(err, "failed to delete the client with id %q", id)
 	}
 	if res.NotFound {
-		return fmt.Errorf("delete did not find the client with id %q", id)
+		return errors.Wrapf(idp.ErrClientNotFound, "delete did not find the client with id %q", id)
 	}
 	return nil
 }
+
+// GetClient returns the client with the given ID registered in dex. A dex which does not serve the GetClient call
+// yet is asked instead for an update which does not change any field, which only tells whether the client exists
+// and is a write to the storage of dex.
+func (c *Client) GetClient(ctx context.Context, id string) (*idp.Client, error) {
+	res, err := c.dex.GetClient(ctx, &api.GetClientReq{Id: id})
+	if status.Code(err) == codes.Unimplemented {
+		return c.lookupClient(ctx, id)
+	}
+	if isNotFound(err) {
+		return nil, errors.Wrapf(idp.ErrClientNotFound, "did not find the client with id %q", id)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the client with id %q", id)
+	}
+	return fromAPIClient(res.GetClient()), nil
+}
+
+// lookupClient checks that the client with the given ID exists with an update which does not change any field,
+// only the ID of the client is returned
+func (c *Client) lookupClient(ctx context.Context, id string) (*idp.Client, error) {
+	res, err := c.dex.UpdateClient(ctx, &api.UpdateClientReq{Id: id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to look up the client with id %q", id)
+	}
+	if res.NotFound {
+		return nil, errors.Wrapf(idp.ErrClientNotFound, "did not find the client with id %q", id)
+	}
+	return &idp.Client{ID: id}, nil
+}
+
+// ListClients returns all the clients registered in dex, without their secrets. It fails with a dex which does not
+// serve the ListClients call yet.
+func (c *Client) ListClients(ctx context.Context) ([]*idp.Client, error) {
+	res, err := c.dex.ListClients(ctx, &api.ListClientReq{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the clients")
+	}
+	clients := make([]*idp.Client, 0, len(res.GetClients()))
+	for _, info := range res.GetClients() {
+		clients = append(clients, &idp.Client{
+			ID:           info.GetId(),
+			RedirectURIs: info.GetRedirectUris(),
+			TrustedPeers: info.GetTrustedPeers(),
+			Public:       info.GetPublic(),
+			Name:         info.GetName(),
+			LogoURL:      info.GetLogoUrl(),
+		})
+	}
+	return clients, nil
+}
+
+// isNotFound tells if a gRPC call failed because the client does not exist. Dex answers a read of a missing client
+// with an unknown error which wraps the not found error of its storage.
+func isNotFound(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	return st.Code() == codes.NotFound ||
+		(st.Code() == codes.Unknown && strings.HasSuffix(st.Message(), "not found"))
+}
+
+// CheckVersion fails when the dex gRPC API is older than the API used by the operator. The API version is
+// increased by dex every time a call is added, hence any newer version is supported.
+func (c *Client) CheckVersion(ctx context.Context) (*api.VersionResp, error) {
+	res, err := c.dex.GetVersion(ctx, &api.VersionReq{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the version of the dex API")
+	}
+	if res.GetApi() < MinAPIVersion {
+		return nil, fmt.Errorf("dex %s serves the gRPC API version %d, the version %d or later is required",
+			res.GetServer(), res.GetApi(), MinAPIVersion)
+	}
+	return res, nil
+}
+
+func fromAPIClient(client *api.Client) *idp.Client {
+	return &idp.Client{
+		ID:           client.GetId(),
+		Secret:       client.GetSecret(),
+		RedirectURIs: client.GetRedirectUris(),
+		TrustedPeers: client.GetTrustedPeers(),
+		Public:       client.GetPublic(),
+		Name:         client.GetName(),
+		LogoURL:      client.GetLogoUrl(),
+	}
+}
+
+// Ping checks if the dex gRPC server answers, the version of the API is requested since it has no side effect
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.dex.GetVersion(ctx, &api.VersionReq{})
+	return errors.Wrap(err, "getting the version of the dex API")
+}
@@ -0,0 +1,288 @@
+package dex
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/dexidp/dex/api/v2"
+	"github.com/jenkins-x/sso-operator/pkg/dex/dextest"
+	"github.com/jenkins-x/sso-operator/pkg/idp"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestClient(t *testing.T, configure ...func(*Options)) (*Client, *dextest.Server) {
+	server, err := dextest.NewServer()
+	require.NoError(t, err)
+	opts := &Options{
+		HostAndPort: server.Addr,
+		ClientCrt:   server.CertFile,
+		ClientKey:   server.KeyFile,
+		ClientCA:    server.CAFile,
+	}
+	for _, c := range configure {
+		c(opts)
+	}
+	client, err := NewClient(opts)
+	if err != nil {
+		server.Close()
+	}
+	require.NoError(t, err)
+	return client, server
+}
+
+func TestClientLifecycle(t *testing.T) {
+	client, server := newTestClient(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	require.NoError(t, client.Ping(ctx))
+
+	created, err := client.CreateClient(ctx, &idp.Client{
+		ID:           "test-sso-1",
+		Secret:       "secret",
+		RedirectURIs: []string{"https://fake"},
+		Name:         "test-sso",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "test-sso-1", created.ID)
+	assert.Equal(t, "secret", created.Secret)
+
+	created.RedirectURIs = []string{"https://test.example.com/oauth2/callback"}
+	require.NoError(t, client.UpdateClient(ctx, created))
+	assert.Equal(t, []string{"https://test.example.com/oauth2/callback"}, server.Client("test-sso-1").GetRedirectUris())
+
+	require.NoError(t, client.DeleteClient(ctx, "test-sso-1"))
+	assert.Equal(t, 0, server.Len())
+}
+
+func TestCreateClientAdoptsExistingClient(t *testing.T) {
+	client, server := newTestClient(t)
+	defer server.Close()
+	server.AddClient(&api.Client{Id: "test-sso-1", Secret: "secret", Name: "test-sso"})
+
+	created, err := client.CreateClient(context.Background(), &idp.Client{ID: "test-sso-1", Secret: "secret"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "test-sso-1", created.ID)
+	assert.Equal(t, "secret", created.Secret)
+	assert.Equal(t, 1, server.Len())
+}
+
+func TestClientNotFound(t *testing.T) {
+	client, server := newTestClient(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	err := client.UpdateClient(ctx, &idp.Client{ID: "missing"})
+	assert.True(t, idp.IsNotFound(err))
+	err = client.DeleteClient(ctx, "missing")
+	assert.True(t, idp.IsNotFound(err))
+
+	server.AddClient(&api.Client{Id: "test-sso-1"})
+	server.Intercept(dextest.DeleteClient, dextest.NotFound())
+	err = client.DeleteClient(ctx, "test-sso-1")
+	assert.True(t, idp.IsNotFound(err))
+	assert.Equal(t, 1, server.Len())
+}
+
+func TestGetClient(t *testing.T) {
+	client, server := newTestClient(t)
+	defer server.Close()
+	ctx := context.Background()
+	server.AddClient(&api.Client{Id: "test-sso-1", Secret: "secret", RedirectUris: []string{"https://fake"}})
+
+	found, err := client.GetClient(ctx, "test-sso-1")
+	require.NoError(t, err)
+	assert.Equal(t, "test-sso-1", found.ID)
+	assert.Equal(t, []string{"https://fake"}, found.RedirectURIs)
+	assert.Equal(t, 0, server.Calls(dextest.UpdateClient))
+
+	_, err = client.GetClient(ctx, "missing")
+	assert.True(t, idp.IsNotFound(err))
+
+	server.Intercept(dextest.GetClient, dextest.Fail(status.Error(codes.Internal, "storage failure")))
+	_, err = client.GetClient(ctx, "test-sso-1")
+	assert.Error(t, err)
+	assert.False(t, idp.IsNotFound(err))
+}
+
+func TestGetClientWithoutGetClientCall(t *testing.T) {
+	client, server := newTestClient(t)
+	defer server.Close()
+	ctx := context.Background()
+	server.AddClient(&api.Client{Id: "test-sso-1", Secret: "secret", RedirectUris: []string{"https://fake"}})
+	unimplemented := dextest.Fail(status.Error(codes.Unimplemented, "unknown method GetClient"))
+
+	server.Intercept(dextest.GetClient, unimplemented)
+	found, err := client.GetClient(ctx, "test-sso-1")
+	require.NoError(t, err)
+	assert.Equal(t, "test-sso-1", found.ID)
+	assert.Equal(t, []string{"https://fake"}, server.Client("test-sso-1").GetRedirectUris(),
+		"the lookup does not change the client")
+
+	server.Intercept(dextest.GetClient, unimplemented)
+	_, err = client.GetClient(ctx, "missing")
+	assert.True(t, idp.IsNotFound(err))
+	assert.Equal(t, 2, server.Calls(dextest.UpdateClient))
+}
+
+func TestListClients(t *testing.T) {
+	client, server := newTestClient(t)
+	defer server.Close()
+	ctx := context.Background()
+	server.AddClient(&api.Client{Id: "test-sso-1", Secret: "secret", Name: "test-sso"})
+	server.AddClient(&api.Client{Id: "test-sso-2", Secret: "secret", RedirectUris: []string{"https://fake"}})
+
+	clients, err := client.ListClients(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*idp.Client{
+		{ID: "test-sso-1", Name: "test-sso"},
+		{ID: "test-sso-2", RedirectURIs: []string{"https://fake"}},
+	}, clients)
+
+	server.Intercept(dextest.ListClients, dextest.Fail(status.Error(codes.Unimplemented, "unknown method ListClients")))
+	_, err = client.ListClients(ctx)
+	assert.Equal(t, codes.Unimplemented, status.Code(errors.Cause(err)))
+}
+
+func TestCheckVersion(t *testing.T) {
+	client, server := newTestClient(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	version, err := client.CheckVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(dextest.APIVersion), version.GetApi())
+
+	server.Intercept(dextest.GetVersion, func(interface{}) (interface{}, error) {
+		return &api.VersionResp{Server: "v2.0.0", Api: -1}, nil
+	})
+	_, err = client.CheckVersion(ctx)
+	assert.Error(t, err)
+
+	server.Intercept(dextest.GetVersion, dextest.Fail(status.Error(codes.Unimplemented, "unknown method")))
+	_, err = client.CheckVersion(ctx)
+	assert.Error(t, err)
+}
+
+func TestClientErrors(t *testing.T) {
+	client, server := newTestClient(t)
+	defer server.Close()
+	ctx := context.Background()
+	internal := status.Error(codes.Internal, "storage failure")
+
+	server.Intercept(dextest.CreateClient, dextest.Fail(internal))
+	_, err := client.CreateClient(ctx, &idp.Client{ID: "test-sso-1"})
+	assert.Error(t, err)
+	assert.Equal(t, codes.Internal, status.Code(errors.Cause(err)))
+	assert.Equal(t, 0, server.Len())
+
+	server.Intercept(dextest.DeleteClient, dextest.Fail(internal))
+	err = client.DeleteClient(ctx, "test-sso-1")
+	assert.Error(t, err)
+	assert.False(t, idp.IsNotFound(err))
+	assert.Equal(t, 1, server.Calls(dextest.CreateClient))
+	assert.Equal(t, 1, server.Calls(dextest.DeleteClient))
+}
+
+func TestNewClientRequiresTheCA(t *testing.T) {
+	_, err := NewClient(&Options{HostAndPort: "localhost:5557", ClientCA: "/missing/ca.crt"})
+	assert.Error(t, err)
+}
+
+func TestNewClientRejectsShortKeepalive(t *testing.T) {
+	server, err := dextest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	_, err = NewClient(&Options{
+		HostAndPort:   server.Addr,
+		ClientCrt:     server.CertFile,
+		ClientKey:     server.KeyFile,
+		ClientCA:      server.CAFile,
+		KeepaliveTime: time.Minute,
+	})
+
+	assert.Error(t, err)
+}
+
+func TestClientRetriesWhileUnavailable(t *testing.T) {
+	client, server := newTestClient(t, func(opts *Options) { opts.Retries = 2 })
+	defer server.Close()
+	ctx := context.Background()
+	unavailable := status.Error(codes.Unavailable, "storage unavailable")
+
+	server.Intercept(dextest.CreateClient, dextest.Fail(unavailable))
+	server.Intercept(dextest.CreateClient, dextest.Fail(unavailable))
+	_, err := client.CreateClient(ctx, &idp.Client{ID: "test-sso-1"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, server.Calls(dextest.CreateClient))
+	assert.Equal(t, 1, server.Len())
+
+	for i := 0; i < 3; i++ {
+		server.Intercept(dextest.DeleteClient, dextest.Fail(unavailable))
+	}
+	err = client.DeleteClient(ctx, "test-sso-1")
+	assert.Equal(t, codes.Unavailable, status.Code(errors.Cause(err)))
+	assert.Equal(t, 3, server.Calls(dextest.DeleteClient))
+	assert.Equal(t, 1, server.Len())
+}
+
+func TestClientTimeout(t *testing.T) {
+	client, server := newTestClient(t, func(opts *Options) {
+		opts.Timeout = 100 * time.Millisecond
+		opts.Retries = 2
+	})
+	defer server.Close()
+	server.Intercept(dextest.CreateClient, func(interface{}) (interface{}, error) {
+		time.Sleep(time.Second)
+		return nil, nil
+	})
+
+	_, err := client.CreateClient(context.Background(), &idp.Client{ID: "test-sso-1"})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(errors.Cause(err)))
+	assert.Equal(t, 1, server.Calls(dextest.CreateClient))
+}
+
+func TestClientReloadsRotatedCerts(t *testing.T) {
+	client, server := newTestClient(t, func(opts *Options) { opts.Retries = 4 })
+	defer server.Close()
+	ctx := context.Background()
+	require.NoError(t, client.Ping(ctx))
+
+	require.NoError(t, server.RotateCerts())
+
+	_, err := client.CreateClient(ctx, &idp.Client{ID: "test-sso-1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, server.Len())
+}
+
+func TestReloadingCredentialsKeepValidCerts(t *testing.T) {
+	server, err := dextest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+	creds, err := newReloadingCredentials(server.CertFile, server.KeyFile, server.CAFile)
+	require.NoError(t, err)
+	loaded, err := creds.load()
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(server.CertFile, []byte("renewing"), 0600))
+	current, err := creds.load()
+	require.NoError(t, err)
+	assert.True(t, loaded == current, "the invalid certificate should be ignored")
+
+	require.NoError(t, server.RotateCerts())
+	rotated, err := creds.load()
+	require.NoError(t, err)
+	assert.False(t, loaded == rotated, "the renewed certificate should be loaded")
+
+	_, err = newReloadingCredentials(server.CertFile, server.KeyFile, "/missing/ca.crt")
+	assert.Error(t, err)
+}
@@ -0,0 +1,160 @@
+package dex
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/credentials"
+)
+
+// reloadingCredentials are the TLS credentials of the gRPC client, the client certificate, its key and the CA
+// are read again from disk before each handshake. The certificates renewed by cert-manager are used by the
+// next connection to dex without restarting the operator.
+type reloadingCredentials struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu         sync.Mutex
+	files      [][]byte
+	current    credentials.TransportCredentials
+	serverName string
+}
+
+var _ credentials.TransportCredentials = &reloadingCredentials{}
+
+// newReloadingCredentials loads the client certificate and the CA, the files must be valid initially
+func newReloadingCredentials(certFile string, keyFile string, caFile string) (*reloadingCredentials, error) {
+	c := &reloadingCredentials{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+	}
+	_, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load returns the credentials built from the files on disk, the files are only parsed when their content
+// changed. Invalid files, such as files which are being renewed, are ignored once valid credentials were loaded.
+func (c *reloadingCredentials) load() (credentials.TransportCredentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	files, err := c.read()
+	if err == nil && c.current != nil && sameFiles(files, c.files) {
+		return c.current, nil
+	}
+	var creds credentials.TransportCredentials
+	if err == nil {
+		creds, err = tlsCredentials(files[0], files[1], files[2])
+	}
+	if err != nil {
+		if c.current == nil {
+			return nil, err
+		}
+		logrus.Warnf("keeping the current dex gRPC client certificate: %v", err)
+		return c.current, nil
+	}
+	if c.serverName != "" {
+		_ = creds.OverrideServerName(c.serverName) // #nosec
+	}
+	if c.current != nil {
+		logrus.Info("Reloaded the dex gRPC client certificate")
+	}
+	c.files = files
+	c.current = creds
+	return creds, nil
+}
+
+func (c *reloadingCredentials) read() ([][]byte, error) {
+	files := [][]byte{}
+	for _, path := range []string{c.certFile, c.keyFile, c.caFile} {
+		data, err := ioutil.ReadFile(path) // #nosec
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %q", path)
+		}
+		files = append(files, data)
+	}
+	return files, nil
+}
+
+func sameFiles(a [][]byte, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// tlsCredentials builds the TLS credentials from the PEM encoded client certificate, key and CA
+func tlsCredentials(cert []byte, key []byte, ca []byte) (credentials.TransportCredentials, error) {
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("failed to append the CA cert to the certs pool")
+	}
+	clientCert, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading the client cert and private key")
+	}
+	return credentials.NewTLS(&tls.Config{
+		RootCAs:      certPool,
+		Certificates: []tls.Certificate{clientCert},
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
+// ClientHandshake performs the TLS handshake with the latest certificates
+func (c *reloadingCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	creds, err := c.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	return creds.ClientHandshake(ctx, authority, rawConn)
+}
+
+// ServerHandshake is not supported since the credentials are only used by the client
+func (c *reloadingCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("the dex client credentials cannot be used by a server")
+}
+
+// Info returns the protocol information of the current credentials
+func (c *reloadingCredentials) Info() credentials.ProtocolInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current.Info()
+}
+
+// Clone returns a copy of the credentials which reloads the same files
+func (c *reloadingCredentials) Clone() credentials.TransportCredentials {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &reloadingCredentials{
+		certFile:   c.certFile,
+		keyFile:    c.keyFile,
+		caFile:     c.caFile,
+		files:      c.files,
+		current:    c.current.Clone(),
+		serverName: c.serverName,
+	}
+}
+
+// OverrideServerName overrides the server name used to verify the certificate of dex
+func (c *reloadingCredentials) OverrideServerName(serverName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.serverName = serverName
+	return c.current.OverrideServerName(serverName)
+}
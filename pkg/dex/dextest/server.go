@@ -0,0 +1,423 @@
+// Package dextest provides an in memory dex gRPC server to test the operator without a live dex
+package dextest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dexidp/dex/api/v2"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// The names of the calls which can be intercepted
+const (
+	CreateClient = "CreateClient"
+	UpdateClient = "UpdateClient"
+	DeleteClient = "DeleteClient"
+	GetClient    = "GetClient"
+	ListClients  = "ListClients"
+	GetVersion   = "GetVersion"
+)
+
+// APIVersion is the version of the dex API reported by the server
+const APIVersion = 2
+
+// Hook intercepts a call of the fake server, it returns the response or the error of the call instead of the
+// in memory clients. A nil response and error lets the call through.
+type Hook func(req interface{}) (interface{}, error)
+
+// Fail returns a hook which fails the call with the given error
+func Fail(err error) Hook {
+	return func(interface{}) (interface{}, error) {
+		return nil, err
+	}
+}
+
+// AlreadyExists returns a hook which answers a client creation as if the client already existed, without
+// storing the requested client
+func AlreadyExists() Hook {
+	return func(interface{}) (interface{}, error) {
+		return &api.CreateClientResp{AlreadyExists: true}, nil
+	}
+}
+
+// NotFound returns a hook which answers the update or the deletion of a client as if the client did not exist
+func NotFound() Hook {
+	return func(req interface{}) (interface{}, error) {
+		switch req.(type) {
+		case *api.UpdateClientReq:
+			return &api.UpdateClientResp{NotFound: true}, nil
+		case *api.DeleteClientReq:
+			return &api.DeleteClientResp{NotFound: true}, nil
+		}
+		return nil, errors.Errorf("unexpected request %T", req)
+	}
+}
+
+// Server is a dex gRPC server which keeps the OIDC clients in memory. It requires a client certificate
+// signed by its CA, like dex does.
+type Server struct {
+	api.UnimplementedDexServer
+
+	// Addr is the host and port of the server
+	Addr string
+	// CAFile, CertFile and KeyFile are the CA and the client certificate and key of the gRPC clients
+	CAFile   string
+	CertFile string
+	KeyFile  string
+
+	dir      string
+	server   *grpc.Server
+	listener net.Listener
+
+	mu      sync.Mutex
+	clients map[string]*api.Client
+	hooks   map[string][]Hook
+	calls   map[string]int
+}
+
+// NewServer starts a fake dex gRPC server on a local port, the server is stopped with Close
+func NewServer() (*Server, error) {
+	dir, err := ioutil.TempDir("", "dextest")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating the certs directory")
+	}
+	s := &Server{
+		CAFile:   filepath.Join(dir, "ca.crt"),
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+		dir:      dir,
+		clients:  map[string]*api.Client{},
+		hooks:    map[string][]Hook{},
+		calls:    map[string]int{},
+	}
+	serverTLS, err := s.writeCerts()
+	if err == nil {
+		err = s.serve("127.0.0.1:0", serverTLS)
+	}
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close stops the server and removes its certificates
+func (s *Server) Close() {
+	s.stop()
+	_ = os.RemoveAll(s.dir)
+}
+
+// RotateCerts issues a new CA with new server and client certificates, like cert-manager does when the CA is
+// renewed, and restarts the server on the same address. The connections of the clients are closed.
+func (s *Server) RotateCerts() error {
+	s.stop()
+	serverTLS, err := s.writeCerts()
+	if err != nil {
+		return err
+	}
+	return s.serve(s.Addr, serverTLS)
+}
+
+// serve starts the gRPC server on the address
+func (s *Server) serve(addr string, serverTLS *tls.Config) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(err, "listening on %s", addr)
+	}
+	s.Addr = listener.Addr().String()
+	s.listener = listener
+	s.server = grpc.NewServer(grpc.Creds(credentials.NewTLS(serverTLS)))
+	api.RegisterDexServer(s.server, s)
+	go s.server.Serve(listener) // #nosec
+	return nil
+}
+
+// stop stops the gRPC server, the listener is closed even when the server did not start serving yet
+func (s *Server) stop() {
+	s.server.Stop()
+	_ = s.listener.Close()
+}
+
+// Intercept queues a hook for the next call of the given method, the hooks are used once in the order they
+// were queued and a nil hook lets the call through
+func (s *Server) Intercept(method string, hook Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks[method] = append(s.hooks[method], hook)
+}
+
+// Calls returns the number of calls of the given method, including the intercepted calls
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// Client returns a copy of the stored client with the given ID, or nil when there is no such client
+func (s *Server) Client(id string) *api.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client, ok := s.clients[id]
+	if !ok {
+		return nil
+	}
+	return proto.Clone(client).(*api.Client)
+}
+
+// Len returns the number of stored clients
+func (s *Server) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// AddClient stores a client as if it had been created earlier
+func (s *Server) AddClient(client *api.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[client.GetId()] = proto.Clone(client).(*api.Client)
+}
+
+// intercept counts the call and returns the result of the next hook of the method
+func (s *Server) intercept(method string, req interface{}) (interface{}, bool, error) {
+	s.mu.Lock()
+	s.calls[method]++
+	hooks := s.hooks[method]
+	if len(hooks) == 0 {
+		s.mu.Unlock()
+		return nil, false, nil
+	}
+	hook := hooks[0]
+	s.hooks[method] = hooks[1:]
+	s.mu.Unlock()
+
+	if hook == nil {
+		return nil, false, nil
+	}
+	res, err := hook(req)
+	if res == nil && err == nil {
+		return nil, false, nil
+	}
+	return res, true, err
+}
+
+// CreateClient stores the client unless a client with the same ID exists
+func (s *Server) CreateClient(ctx context.Context, req *api.CreateClientReq) (*api.CreateClientResp, error) {
+	if res, ok, err := s.intercept(CreateClient, req); ok {
+		if err != nil {
+			return nil, err
+		}
+		return res.(*api.CreateClientResp), nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client := req.GetClient()
+	if _, ok := s.clients[client.GetId()]; ok {
+		return &api.CreateClientResp{AlreadyExists: true}, nil
+	}
+	s.clients[client.GetId()] = proto.Clone(client).(*api.Client)
+	return &api.CreateClientResp{Client: client}, nil
+}
+
+// UpdateClient updates the mutable fields of a stored client
+func (s *Server) UpdateClient(ctx context.Context, req *api.UpdateClientReq) (*api.UpdateClientResp, error) {
+	if res, ok, err := s.intercept(UpdateClient, req); ok {
+		if err != nil {
+			return nil, err
+		}
+		return res.(*api.UpdateClientResp), nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client, ok := s.clients[req.GetId()]
+	if !ok {
+		return &api.UpdateClientResp{NotFound: true}, nil
+	}
+	if req.GetName() != "" {
+		client.Name = req.GetName()
+	}
+	if req.GetLogoUrl() != "" {
+		client.LogoUrl = req.GetLogoUrl()
+	}
+	if req.GetRedirectUris() != nil {
+		client.RedirectUris = req.GetRedirectUris()
+	}
+	if req.GetTrustedPeers() != nil {
+		client.TrustedPeers = req.GetTrustedPeers()
+	}
+	return &api.UpdateClientResp{}, nil
+}
+
+// DeleteClient removes a stored client
+func (s *Server) DeleteClient(ctx context.Context, req *api.DeleteClientReq) (*api.DeleteClientResp, error) {
+	if res, ok, err := s.intercept(DeleteClient, req); ok {
+		if err != nil {
+			return nil, err
+		}
+		return res.(*api.DeleteClientResp), nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clients[req.GetId()]; !ok {
+		return &api.DeleteClientResp{NotFound: true}, nil
+	}
+	delete(s.clients, req.GetId())
+	return &api.DeleteClientResp{}, nil
+}
+
+// GetClient returns a stored client, a missing client fails with the same error as dex
+func (s *Server) GetClient(ctx context.Context, req *api.GetClientReq) (*api.GetClientResp, error) {
+	if res, ok, err := s.intercept(GetClient, req); ok {
+		if err != nil {
+			return nil, err
+		}
+		return res.(*api.GetClientResp), nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client, ok := s.clients[req.GetId()]
+	if !ok {
+		return nil, status.Error(codes.Unknown, "get client: not found")
+	}
+	return &api.GetClientResp{Client: proto.Clone(client).(*api.Client)}, nil
+}
+
+// ListClients returns the stored clients without their secrets, sorted by ID
+func (s *Server) ListClients(ctx context.Context, req *api.ListClientReq) (*api.ListClientResp, error) {
+	if res, ok, err := s.intercept(ListClients, req); ok {
+		if err != nil {
+			return nil, err
+		}
+		return res.(*api.ListClientResp), nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := &api.ListClientResp{}
+	for _, client := range s.clients {
+		res.Clients = append(res.Clients, &api.ClientInfo{
+			Id:           client.GetId(),
+			RedirectUris: client.GetRedirectUris(),
+			TrustedPeers: client.GetTrustedPeers(),
+			Public:       client.GetPublic(),
+			Name:         client.GetName(),
+			LogoUrl:      client.GetLogoUrl(),
+		})
+	}
+	sort.Slice(res.Clients, func(i, j int) bool { return res.Clients[i].GetId() < res.Clients[j].GetId() })
+	return res, nil
+}
+
+// GetVersion returns the version of the fake server
+func (s *Server) GetVersion(ctx context.Context, req *api.VersionReq) (*api.VersionResp, error) {
+	if res, ok, err := s.intercept(GetVersion, req); ok {
+		if err != nil {
+			return nil, err
+		}
+		return res.(*api.VersionResp), nil
+	}
+	return &api.VersionResp{Server: "dextest", Api: APIVersion}, nil
+}
+
+// writeCerts generates a CA which signs the server and the client certificates, and writes the CA and the
+// client certificate in the directory of the server
+func (s *Server) writeCerts() (*tls.Config, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating the CA key")
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "dextest-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating the CA certificate")
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the CA certificate")
+	}
+
+	serverCert, _, _, err := issue(ca, caKey, 2, x509.ExtKeyUsageServerAuth)
+	if err != nil {
+		return nil, errors.Wrap(err, "issuing the server certificate")
+	}
+	_, clientCert, clientKey, err := issue(ca, caKey, 3, x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return nil, errors.Wrap(err, "issuing the client certificate")
+	}
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	files := map[string][]byte{s.CAFile: caPEM, s.CertFile: clientCert, s.KeyFile: clientKey}
+	for path, data := range files {
+		err = ioutil.WriteFile(path, data, 0600)
+		if err != nil {
+			return nil, errors.Wrapf(err, "writing %q", path)
+		}
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// issue creates a certificate signed by the CA for the local host, and returns it also PEM encoded with its key
+func issue(ca *x509.Certificate, caKey *ecdsa.PrivateKey, serial int64,
+	usage x509.ExtKeyUsage) (tls.Certificate, []byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	return cert, certPEM, keyPEM, err
+}
@@ -0,0 +1,32 @@
+package dex
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "sso_operator",
+	Subsystem: "dex",
+	Name:      "request_duration_seconds",
+	Help:      "Latency of the gRPC calls to dex by method and status code.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "code"})
+
+func init() {
+	prometheus.MustRegister(requestDuration)
+}
+
+// observeRequest is a gRPC interceptor which records the latency of the calls to dex
+func observeRequest(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	requestDuration.WithLabelValues(path.Base(method), status.Code(err).String()).Observe(time.Since(start).Seconds())
+	return err
+}
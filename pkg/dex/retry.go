@@ -0,0 +1,38 @@
+package dex
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// retryUnavailable returns a gRPC interceptor which bounds every attempt of a call with the timeout, and retries
+// with an exponential backoff the calls which fail because dex is unavailable. Retrying is safe since a client
+// created twice is adopted, and a client deleted twice is reported as not found.
+func retryUnavailable(timeout time.Duration, backoff wait.Backoff) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		delay := backoff.Duration
+		for attempt := 1; ; attempt++ {
+			callCtx, cancel := context.WithTimeout(ctx, timeout)
+			err := invoker(callCtx, method, req, reply, cc, opts...)
+			cancel()
+			if status.Code(err) != codes.Unavailable || attempt >= backoff.Steps {
+				return err
+			}
+			logrus.Debugf("dex is unavailable for %s, retrying in %s: %v", path.Base(method), delay, err)
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(delay):
+			}
+			delay = time.Duration(float64(delay) * backoff.Factor)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/discovery"
+)
+
+// Check verifies that a dependency of the operator is reachable
+type Check func(ctx context.Context) error
+
+// Handler runs the checks on every request, it answers 200 when all checks pass and 503 otherwise. The
+// result of every check is written in the body.
+func Handler(timeout time.Duration, checks map[string]Check) http.Handler {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthy := true
+		body := ""
+		for _, name := range names {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			err := checks[name](ctx)
+			cancel()
+			if err != nil {
+				healthy = false
+				logrus.Warnf("health check %s failed: %v", name, err)
+				body += fmt.Sprintf("[-]%s failed: %v\n", name, err)
+				continue
+			}
+			body += fmt.Sprintf("[+]%s ok\n", name)
+		}
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		fmt.Fprint(w, body)
+	})
+}
+
+// Kubernetes checks if the Kubernetes API server is reachable by reading its version. The discovery client does
+// not take a context, the check gives up when the context is done and the call is bounded by the client timeout.
+func Kubernetes(client discovery.ServerVersionInterface) Check {
+	return func(ctx context.Context) error {
+		errs := make(chan error, 1)
+		go func() {
+			_, err := client.ServerVersion()
+			errs <- err
+		}()
+		select {
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func TestHandler(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	failed := func(ctx context.Context) error { return errors.New("connection refused") }
+
+	tests := map[string]struct {
+		checks map[string]Check
+		code   int
+		body   string
+	}{
+		"all checks pass": {
+			checks: map[string]Check{"kubernetes": ok, "dex": ok},
+			code:   http.StatusOK,
+			body:   "[+]dex ok\n[+]kubernetes ok\n",
+		},
+		"a check fails": {
+			checks: map[string]Check{"kubernetes": ok, "dex": failed},
+			code:   http.StatusServiceUnavailable,
+			body:   "[-]dex failed: connection refused\n[+]kubernetes ok\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Handler(time.Second, tc.checks).ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+			assert.Equal(t, tc.code, rec.Code)
+			assert.Equal(t, tc.body, rec.Body.String())
+		})
+	}
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	blocked := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	rec := httptest.NewRecorder()
+	Handler(10*time.Millisecond, map[string]Check{"dex": blocked}).ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "[-]dex failed: context deadline exceeded\n", rec.Body.String())
+}
+
+type blockedVersion struct {
+	release chan struct{}
+}
+
+func (v *blockedVersion) ServerVersion() (*version.Info, error) {
+	<-v.release
+	return &version.Info{}, nil
+}
+
+func TestKubernetesTimeout(t *testing.T) {
+	client := &blockedVersion{release: make(chan struct{})}
+	defer close(client.release)
+	rec := httptest.NewRecorder()
+	Handler(10*time.Millisecond, map[string]Check{"kubernetes": Kubernetes(client)}).ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "[-]kubernetes failed: context deadline exceeded\n", rec.Body.String())
+}
@@ -0,0 +1,51 @@
+package idp
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ErrClientNotFound is returned when the OIDC client is not registered in the identity provider
+var ErrClientNotFound = errors.New("client not found")
+
+// IsNotFound checks if the error was caused by an OIDC client which is not registered in the identity provider
+func IsNotFound(err error) bool {
+	return errors.Cause(err) == ErrClientNotFound
+}
+
+// Client is an OIDC client registered in an identity provider
+type Client struct {
+	// ID identifies the client in the operator, it is chosen by the operator in order to retry a registration
+	ID string
+	// IssuedID is the client ID assigned by an identity provider which does not let the operator choose it
+	IssuedID     string
+	Secret       string
+	RedirectURIs []string
+	TrustedPeers []string
+	Public       bool
+	Name         string
+	LogoURL      string
+}
+
+// OIDCClientID returns the client ID with which the relying party authenticates to the identity provider
+func (c *Client) OIDCClientID() string {
+	if c.IssuedID != "" {
+		return c.IssuedID
+	}
+	return c.ID
+}
+
+// Registrar registers the OIDC clients of the SSOs in an identity provider
+type Registrar interface {
+	// CreateClient registers a new OIDC client. The registration can be retried with the same ID, the client
+	// already registered with this ID is returned instead. A provider which generates the client ID and secret
+	// returns them in the registered client.
+	CreateClient(ctx context.Context, client *Client) (*Client, error)
+	// UpdateClient updates the redirect URIs and the metadata of an already registered client
+	UpdateClient(ctx context.Context, client *Client) error
+	// DeleteClient removes the client with the given ID, ErrClientNotFound is returned when it does not exist
+	DeleteClient(ctx context.Context, id string) error
+	// GetClient returns the client with the given ID, ErrClientNotFound is returned when it does not exist
+	GetClient(ctx context.Context, id string) (*Client, error)
+}
@@ -0,0 +1,412 @@
+package keycloak
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/sso-operator/pkg/idp"
+	"github.com/pkg/errors"
+)
+
+// tokenExpiryMargin renews the access token of the admin API before it expires
+const tokenExpiryMargin = 10 * time.Second
+
+// Options keeps the configuration of the Keycloak admin API client
+type Options struct {
+	// URL of the Keycloak server, including the /auth context path of the versions which have one
+	URL string
+	// Realm in which the OIDC clients are created
+	Realm string
+	// AdminRealm is the realm of the credentials of the operator
+	AdminRealm string
+	// AdminClientID is the client with which the operator authenticates
+	AdminClientID string
+	// AdminClientSecret authenticates the operator with the client credentials grant of a service account
+	AdminClientSecret string
+	// Username and Password authenticate the operator with the password grant when no client secret is provided
+	Username string
+	Password string
+	// GroupsClaim is the claim in which the groups of the user are mapped, the mapper is not created when empty
+	GroupsClaim string
+	// RolesClaim is the claim in which the realm roles of the user are mapped, the mapper is not created when empty
+	RolesClaim string
+	// Timeout of the requests to Keycloak
+	Timeout time.Duration
+}
+
+// Client manages the OIDC clients of the SSOs as confidential clients of a Keycloak realm through the admin
+// REST API. The client ID and secret chosen by the operator are kept by Keycloak.
+type Client struct {
+	opts Options
+	http *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+var _ idp.Registrar = &Client{}
+
+// clientRepresentation is the subset of the Keycloak client representation managed by the operator, the
+// other fields are kept on update
+type clientRepresentation map[string]interface{}
+
+// protocolMapper adds a claim to the tokens issued for a client
+type protocolMapper struct {
+	Name           string            `json:"name"`
+	Protocol       string            `json:"protocol"`
+	ProtocolMapper string            `json:"protocolMapper"`
+	Config         map[string]string `json:"config"`
+}
+
+// responseError is a failed request to the admin API
+type responseError struct {
+	StatusCode   int
+	ErrorMessage string `json:"errorMessage"`
+	Code         string `json:"error"`
+	Description  string `json:"error_description"`
+}
+
+func (e *responseError) Error() string {
+	message := strings.TrimSpace(e.ErrorMessage + " " + e.Code + " " + e.Description)
+	if message == "" {
+		return fmt.Sprintf("keycloak responded with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("keycloak responded with status %d: %s", e.StatusCode, message)
+}
+
+// NewClient creates a new Keycloak admin API client
+func NewClient(opts *Options) (*Client, error) {
+	if opts.URL == "" {
+		return nil, errors.New("keycloak URL is empty")
+	}
+	_, err := url.ParseRequestURI(opts.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing the keycloak URL %q", opts.URL)
+	}
+	if opts.Realm == "" {
+		return nil, errors.New("keycloak realm is empty")
+	}
+	if opts.AdminClientSecret == "" && opts.Username == "" {
+		return nil, errors.New("either a client secret or a username is required to authenticate to keycloak")
+	}
+	c := &Client{
+		opts: *opts,
+		http: &http.Client{Timeout: opts.Timeout},
+	}
+	c.opts.URL = strings.TrimSuffix(opts.URL, "/")
+	if c.opts.AdminRealm == "" {
+		c.opts.AdminRealm = "master"
+	}
+	if c.opts.AdminClientID == "" {
+		c.opts.AdminClientID = "admin-cli"
+	}
+	return c, nil
+}
+
+// CreateClient creates a confidential client in the realm with the given ID and secret. An already existing
+// client with the same ID is adopted, which allows the creation to be safely retried.
+func (c *Client) CreateClient(ctx context.Context, client *idp.Client) (*idp.Client, error) {
+	existing, err := c.findClient(ctx, client.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "searching the client with id %q", client.ID)
+	}
+	if existing != nil {
+		return c.toClient(ctx, existing)
+	}
+
+	rep := clientRepresentation{
+		"clientId":                  client.ID,
+		"protocol":                  "openid-connect",
+		"enabled":                   true,
+		"clientAuthenticatorType":   "client-secret",
+		"standardFlowEnabled":       true,
+		"implicitFlowEnabled":       false,
+		"directAccessGrantsEnabled": false,
+		"serviceAccountsEnabled":    false,
+		"protocolMappers":           c.protocolMappers(),
+	}
+	setClientFields(rep, client)
+	if !client.Public {
+		rep["secret"] = client.Secret
+	}
+	err = c.do(ctx, http.MethodPost, c.adminURL("clients"), rep, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the OIDC client")
+	}
+	created := *client
+	return &created, nil
+}
+
+// UpdateClient updates the redirect URIs and the metadata of an existing client, the missing mappers are added
+func (c *Client) UpdateClient(ctx context.Context, client *idp.Client) error {
+	existing, err := c.findClient(ctx, client.ID)
+	if err != nil {
+		return errors.Wrapf(err, "searching the client with id %q", client.ID)
+	}
+	if existing == nil {
+		return errors.Wrapf(idp.ErrClientNotFound, "update did not find the client with id %q", client.ID)
+	}
+	setClientFields(existing, client)
+	id := stringField(existing, "id")
+	err = c.do(ctx, http.MethodPut, c.adminURL("clients", id), existing, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update the client with id %q", client.ID)
+	}
+
+	// The mappers are not updated with the client, they are managed by a sub-resource
+	mappers := map[string]bool{}
+	if list, ok := existing["protocolMappers"].([]interface{}); ok {
+		for _, m := range list {
+			if mapper, ok := m.(map[string]interface{}); ok {
+				mappers[stringField(mapper, "name")] = true
+			}
+		}
+	}
+	for _, mapper := range c.protocolMappers() {
+		if mappers[mapper.Name] {
+			continue
+		}
+		err = c.do(ctx, http.MethodPost, c.adminURL("clients", id, "protocol-mappers", "models"), mapper, nil)
+		if err != nil {
+			return errors.Wrapf(err, "adding the %s mapper to the client with id %q", mapper.Name, client.ID)
+		}
+	}
+	return nil
+}
+
+// DeleteClient deletes the client with the given ID from the realm
+func (c *Client) DeleteClient(ctx context.Context, id string) error {
+	existing, err := c.findClient(ctx, id)
+	if err != nil {
+		return errors.Wrapf(err, "searching the client with id %q", id)
+	}
+	if existing == nil {
+		return errors.Wrapf(idp.ErrClientNotFound, "delete did not find the client with id %q", id)
+	}
+	err = c.do(ctx, http.MethodDelete, c.adminURL("clients", stringField(existing, "id")), nil, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete the client with id %q", id)
+	}
+	return nil
+}
+
+// GetClient returns the client with the given ID together with its secret
+func (c *Client) GetClient(ctx context.Context, id string) (*idp.Client, error) {
+	existing, err := c.findClient(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "searching the client with id %q", id)
+	}
+	if existing == nil {
+		return nil, errors.Wrapf(idp.ErrClientNotFound, "did not find the client with id %q", id)
+	}
+	return c.toClient(ctx, existing)
+}
+
+// Ping checks if the admin API of the realm is reachable with the credentials of the operator
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, c.adminURL(), nil, nil)
+}
+
+// findClient returns the representation of the client with the given client ID, or nil when it does not exist
+func (c *Client) findClient(ctx context.Context, clientID string) (clientRepresentation, error) {
+	clients := []clientRepresentation{}
+	err := c.do(ctx, http.MethodGet, c.adminURL("clients")+"?clientId="+url.QueryEscape(clientID), nil, &clients)
+	if err != nil {
+		return nil, err
+	}
+	for _, client := range clients {
+		if stringField(client, "clientId") == clientID {
+			return client, nil
+		}
+	}
+	return nil, nil
+}
+
+// toClient converts a client representation, the secret of a confidential client is read from its sub-resource
+func (c *Client) toClient(ctx context.Context, rep clientRepresentation) (*idp.Client, error) {
+	client := &idp.Client{
+		ID:   stringField(rep, "clientId"),
+		Name: stringField(rep, "name"),
+	}
+	client.Public, _ = rep["publicClient"].(bool)
+	if uris, ok := rep["redirectUris"].([]interface{}); ok {
+		for _, uri := range uris {
+			if s, ok := uri.(string); ok {
+				client.RedirectURIs = append(client.RedirectURIs, s)
+			}
+		}
+	}
+	if attributes, ok := rep["attributes"].(map[string]interface{}); ok {
+		client.LogoURL, _ = attributes["logoUri"].(string)
+	}
+	if client.Public {
+		return client, nil
+	}
+	secret := struct {
+		Value string `json:"value"`
+	}{}
+	err := c.do(ctx, http.MethodGet, c.adminURL("clients", stringField(rep, "id"), "client-secret"), nil, &secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the secret of the client with id %q", client.ID)
+	}
+	client.Secret = secret.Value
+	return client, nil
+}
+
+// setClientFields copies the fields managed by the operator in a client representation
+func setClientFields(rep clientRepresentation, client *idp.Client) {
+	rep["name"] = client.Name
+	rep["publicClient"] = client.Public
+	rep["redirectUris"] = client.RedirectURIs
+	attributes, ok := rep["attributes"].(map[string]interface{})
+	if !ok {
+		attributes = map[string]interface{}{}
+	}
+	if client.LogoURL != "" {
+		attributes["logoUri"] = client.LogoURL
+	}
+	rep["attributes"] = attributes
+}
+
+// protocolMappers returns the mappers which add the groups and the realm roles of the users to the tokens
+func (c *Client) protocolMappers() []protocolMapper {
+	mappers := []protocolMapper{}
+	claims := map[string]string{
+		"id.token.claim":       "true",
+		"access.token.claim":   "true",
+		"userinfo.token.claim": "true",
+	}
+	if c.opts.GroupsClaim != "" {
+		config := map[string]string{"claim.name": c.opts.GroupsClaim, "full.path": "false"}
+		for k, v := range claims {
+			config[k] = v
+		}
+		mappers = append(mappers, protocolMapper{
+			Name:           "groups",
+			Protocol:       "openid-connect",
+			ProtocolMapper: "oidc-group-membership-mapper",
+			Config:         config,
+		})
+	}
+	if c.opts.RolesClaim != "" {
+		config := map[string]string{"claim.name": c.opts.RolesClaim, "multivalued": "true", "jsonType.label": "String"}
+		for k, v := range claims {
+			config[k] = v
+		}
+		mappers = append(mappers, protocolMapper{
+			Name:           "realm roles",
+			Protocol:       "openid-connect",
+			ProtocolMapper: "oidc-usermodel-realm-role-mapper",
+			Config:         config,
+		})
+	}
+	return mappers
+}
+
+func (c *Client) adminURL(path ...string) string {
+	parts := []string{c.opts.URL, "admin", "realms", url.PathEscape(c.opts.Realm)}
+	for _, p := range path {
+		parts = append(parts, url.PathEscape(p))
+	}
+	return strings.Join(parts, "/")
+}
+
+// token returns an access token of the admin API, a new token is requested shortly before the current one expires
+func (c *Client) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.accessToken != "" && time.Now().Before(c.expiry) {
+		return c.accessToken, nil
+	}
+
+	form := url.Values{"client_id": {c.opts.AdminClientID}}
+	if c.opts.AdminClientSecret != "" {
+		form.Set("grant_type", "client_credentials")
+		form.Set("client_secret", c.opts.AdminClientSecret)
+	} else {
+		form.Set("grant_type", "password")
+		form.Set("username", c.opts.Username)
+		form.Set("password", c.opts.Password)
+	}
+	tokenURL := strings.Join([]string{c.opts.URL, "realms", url.PathEscape(c.opts.AdminRealm), "protocol",
+		"openid-connect", "token"}, "/")
+	req, err := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", errors.Wrap(err, "creating the token request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	token := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{}
+	err = c.send(req, &token)
+	if err != nil {
+		return "", errors.Wrap(err, "requesting an access token of the keycloak admin API")
+	}
+	c.accessToken = token.AccessToken
+	c.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryMargin)
+	return c.accessToken, nil
+}
+
+// do sends an authenticated JSON request to the admin API and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method string, url string, in interface{}, out interface{}) error {
+	token, err := c.token(ctx)
+	if err != nil {
+		return err
+	}
+	var body []byte
+	if in != nil {
+		body, err = json.Marshal(in)
+		if err != nil {
+			return errors.Wrap(err, "marshaling the request")
+		}
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "creating the request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return c.send(req, out)
+}
+
+func (c *Client) send(req *http.Request, out interface{}) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "reading the response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respErr := &responseError{}
+		_ = json.Unmarshal(data, respErr)
+		respErr.StatusCode = resp.StatusCode
+		return respErr
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return errors.Wrap(json.Unmarshal(data, out), "unmarshaling the response")
+}
+
+func stringField(m map[string]interface{}, field string) string {
+	value, _ := m[field].(string)
+	return value
+}
@@ -0,0 +1,176 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/sso-operator/pkg/idp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKeycloak implements the token endpoint and the client endpoints of the admin API
+type fakeKeycloak struct {
+	server  *httptest.Server
+	clients map[string]map[string]interface{}
+	mappers map[string][]string
+	tokens  int
+	seq     int
+}
+
+func newFakeKeycloak() *fakeKeycloak {
+	k := &fakeKeycloak{clients: map[string]map[string]interface{}{}, mappers: map[string][]string{}}
+	k.server = httptest.NewServer(http.HandlerFunc(k.serve))
+	return k
+}
+
+func (k *fakeKeycloak) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/realms/master/protocol/openid-connect/token" {
+		_ = r.ParseForm()
+		if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_secret") != "admin-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized_client","error_description":"Invalid client secret"}`))
+			return
+		}
+		k.tokens++
+		_, _ = w.Write([]byte(`{"access_token":"admin-token","expires_in":300}`))
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer admin-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/admin/realms/test")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		_, _ = w.Write([]byte(`{"realm":"test"}`))
+	case path == "/clients" && r.Method == http.MethodGet:
+		found := []map[string]interface{}{}
+		for _, client := range k.clients {
+			if client["clientId"] == r.URL.Query().Get("clientId") {
+				found = append(found, client)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(found)
+	case path == "/clients" && r.Method == http.MethodPost:
+		client := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&client)
+		k.seq++
+		id := fmt.Sprintf("uuid-%d", k.seq)
+		client["id"] = id
+		for _, m := range client["protocolMappers"].([]interface{}) {
+			k.mappers[id] = append(k.mappers[id], m.(map[string]interface{})["name"].(string))
+		}
+		k.clients[id] = client
+		w.WriteHeader(http.StatusCreated)
+	case len(parts) == 2 && r.Method == http.MethodPut:
+		client := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&client)
+		k.clients[parts[1]] = client
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		delete(k.clients, parts[1])
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 3 && parts[2] == "client-secret":
+		_, _ = fmt.Fprintf(w, `{"type":"secret","value":%q}`, k.clients[parts[1]]["secret"])
+	case len(parts) == 4 && parts[2] == "protocol-mappers" && r.Method == http.MethodPost:
+		mapper := protocolMapper{}
+		_ = json.NewDecoder(r.Body).Decode(&mapper)
+		k.mappers[parts[1]] = append(k.mappers[parts[1]], mapper.Name)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestClient(t *testing.T, k *fakeKeycloak, groupsClaim string) *Client {
+	c, err := NewClient(&Options{
+		URL:               k.server.URL + "/",
+		Realm:             "test",
+		AdminClientID:     "sso-operator",
+		AdminClientSecret: "admin-secret",
+		GroupsClaim:       groupsClaim,
+		RolesClaim:        "roles",
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func TestClientLifecycle(t *testing.T) {
+	k := newFakeKeycloak()
+	defer k.server.Close()
+	c := newTestClient(t, k, "groups")
+	ctx := context.Background()
+
+	created, err := c.CreateClient(ctx, &idp.Client{
+		ID:           "jx-test-123",
+		Secret:       "secret",
+		RedirectURIs: []string{"https://fake/oauth2/callback"},
+		Name:         "test",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "jx-test-123", created.OIDCClientID())
+	assert.Equal(t, "secret", k.clients["uuid-1"]["secret"])
+	assert.Equal(t, false, k.clients["uuid-1"]["publicClient"])
+	assert.Equal(t, []string{"groups", "realm roles"}, k.mappers["uuid-1"])
+
+	// A retried creation adopts the existing client with its secret
+	adopted, err := c.CreateClient(ctx, &idp.Client{ID: "jx-test-123", Secret: "other"})
+	require.NoError(t, err)
+	assert.Equal(t, "secret", adopted.Secret)
+	assert.Equal(t, []string{"https://fake/oauth2/callback"}, adopted.RedirectURIs)
+	assert.Len(t, k.clients, 1)
+
+	created.RedirectURIs = []string{"https://test.example.com/oauth2/callback"}
+	err = c.UpdateClient(ctx, created)
+	require.NoError(t, err)
+	read, err := c.GetClient(ctx, "jx-test-123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://test.example.com/oauth2/callback"}, read.RedirectURIs)
+	assert.Equal(t, "secret", read.Secret)
+
+	err = c.DeleteClient(ctx, "jx-test-123")
+	require.NoError(t, err)
+	assert.Empty(t, k.clients)
+	assert.Equal(t, 1, k.tokens)
+
+	err = c.DeleteClient(ctx, "jx-test-123")
+	assert.True(t, idp.IsNotFound(err))
+	err = c.UpdateClient(ctx, created)
+	assert.True(t, idp.IsNotFound(err))
+	_, err = c.GetClient(ctx, "jx-test-123")
+	assert.True(t, idp.IsNotFound(err))
+}
+
+func TestUpdateClientAddsMissingMappers(t *testing.T) {
+	k := newFakeKeycloak()
+	defer k.server.Close()
+	ctx := context.Background()
+
+	_, err := newTestClient(t, k, "").CreateClient(ctx, &idp.Client{ID: "jx-test-123", Secret: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"realm roles"}, k.mappers["uuid-1"])
+
+	k.clients["uuid-1"]["protocolMappers"] = []interface{}{map[string]interface{}{"name": "realm roles"}}
+	err = newTestClient(t, k, "groups").UpdateClient(ctx, &idp.Client{ID: "jx-test-123"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"realm roles", "groups"}, k.mappers["uuid-1"])
+}
+
+func TestInvalidCredentials(t *testing.T) {
+	k := newFakeKeycloak()
+	defer k.server.Close()
+	c := newTestClient(t, k, "groups")
+	c.opts.AdminClientSecret = "wrong"
+
+	err := c.Ping(context.Background())
+	assert.EqualError(t, err, "requesting an access token of the keycloak admin API: keycloak responded with "+
+		"status 401: unauthorized_client Invalid client secret")
+}
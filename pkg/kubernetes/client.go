@@ -18,13 +18,33 @@ package kubernetes
 
 import (
 	"fmt"
+	"sync"
 
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/sso-operator/pkg/client/clientset/versioned"
 	"github.com/pkg/errors"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Scheme holds the Kubernetes API types and the SSO API types
+var Scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(Scheme))
+	utilruntime.Must(v1.AddToScheme(Scheme))
+}
+
+var (
+	sharedClientOnce sync.Once
+	sharedClient     client.Client
+	sharedClientErr  error
 )
 
 // GetClientset creates a new k8s client
@@ -66,3 +86,18 @@ func GetJenkinsClient() (versioned.Interface, error) {
 
 	return versioned.NewForConfig(config)
 }
+
+// GetClient returns the client which writes the resources owned by the SSOs. The client is shared by the process
+// since it discovers the REST mappings of the cluster when it is created, the objects which are not in the Scheme
+// are handled as unstructured objects.
+func GetClient() (client.Client, error) {
+	sharedClientOnce.Do(func() {
+		config, err := GetClientConfig()
+		if err != nil {
+			sharedClientErr = errors.Wrap(err, "getting client config for controller-runtime client")
+			return
+		}
+		sharedClient, sharedClientErr = client.New(config, client.Options{Scheme: Scheme})
+	})
+	return sharedClient, sharedClientErr
+}
@@ -1,43 +1,152 @@
 package kubernetes
 
 import (
+	"context"
+	"reflect"
+
 	jenkinsio "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io"
-	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
-	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	crdAPIVersion = "apiextensions.k8s.io/v1"
+	crdKind       = "CustomResourceDefinition"
 )
 
-// RegisterSSOCRD ensures that the CRD is registered for SSO
-func RegisterSSOCRD(apiClient apiextensionsclientset.Interface) error {
-	name := "ssos." + jenkinsio.GroupName
-	names := &v1beta1.CustomResourceDefinitionNames{
-		Kind:       "SSO",
-		ListKind:   "SSOList",
-		Plural:     "ssos",
-		Singular:   "sso",
-		ShortNames: []string{"sso"},
+// RegisterSSOCRD ensures that the CRD is registered for SSO, a CRD registered by an older version of the operator
+// is upgraded in place
+func RegisterSSOCRD() error {
+	return registerCRD(ssoCRD())
+}
+
+// ssoCRD builds the apiextensions.k8s.io/v1 CRD of the SSO with the structural schema generated from the API
+// types, the CRD is built as an unstructured object since the schema is generated as unstructured content
+func ssoCRD() *unstructured.Unstructured {
+	columns := []interface{}{
+		map[string]interface{}{
+			"name":        "Phase",
+			"type":        "string",
+			"description": "The lifecycle phase of the SSO",
+			"jsonPath":    ".status.phase",
+		},
+		map[string]interface{}{
+			"name":        "Ready",
+			"type":        "string",
+			"description": "Indicates if the SSO proxy is ready",
+			"jsonPath":    `.status.conditions[?(@.type=="Ready")].status`,
+		},
+		map[string]interface{}{
+			"name":        "URL",
+			"type":        "string",
+			"description": "The public URL of the SSO proxy",
+			"jsonPath":    ".status.urls[0]",
+		},
+		map[string]interface{}{
+			"name":        "Client ID",
+			"type":        "string",
+			"description": "The OIDC client ID registered in dex",
+			"jsonPath":    ".status.clientId",
+		},
+		map[string]interface{}{
+			"name":        "Age",
+			"type":        "date",
+			"description": "The time since the SSO was created",
+			"jsonPath":    ".metadata.creationTimestamp",
+		},
+	}
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"apiVersion": map[string]interface{}{"type": "string"},
+			"kind":       map[string]interface{}{"type": "string"},
+			"metadata":   map[string]interface{}{"type": "object"},
+			"spec":       openAPISchema(reflect.TypeOf(v1.SSOSpec{})),
+			"status":     openAPISchema(reflect.TypeOf(v1.SSOStatus{})),
+		},
 	}
 
-	return registerCRD(apiClient, name, names)
+	crd := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"group": jenkinsio.GroupName,
+				"scope": "Namespaced",
+				"names": map[string]interface{}{
+					"kind":       "SSO",
+					"listKind":   "SSOList",
+					"plural":     "ssos",
+					"singular":   "sso",
+					"shortNames": []interface{}{"sso"},
+				},
+				// The schema is only structural when the unknown fields are pruned, which is not the default of
+				// the CRDs created with apiextensions.k8s.io/v1beta1
+				"preserveUnknownFields": false,
+				"versions": []interface{}{
+					map[string]interface{}{
+						"name":    jenkinsio.Version,
+						"served":  true,
+						"storage": true,
+						"schema": map[string]interface{}{
+							"openAPIV3Schema": schema,
+						},
+						// The status subresource is required in order to update the status without changing the generation
+						"subresources": map[string]interface{}{
+							"status": map[string]interface{}{},
+						},
+						"additionalPrinterColumns": columns,
+					},
+				},
+			},
+		},
+	}
+	crd.SetAPIVersion(crdAPIVersion)
+	crd.SetKind(crdKind)
+	crd.SetName("ssos." + jenkinsio.GroupName)
+	return crd
 }
 
-func registerCRD(apiClient apiextensionsclientset.Interface, name string, names *v1beta1.CustomResourceDefinitionNames) error {
-	_, err := apiClient.ApiextensionsV1beta1().CustomResourceDefinitions().Get(name, metav1.GetOptions{})
-	if err == nil {
+// registerCRD creates the given CRD or replaces its spec when it differs from the registered CRD
+func registerCRD(crd *unstructured.Unstructured) error {
+	c, err := GetClient()
+	if err != nil {
+		return errors.Wrap(err, "getting controller-runtime client")
+	}
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(crd.GroupVersionKind())
+	err = c.Get(context.TODO(), types.NamespacedName{Name: crd.GetName()}, current)
+	if apierrors.IsNotFound(err) {
+		err = c.Create(context.TODO(), crd)
+		return errors.Wrapf(err, "creating CRD '%s'", crd.GetName())
+	}
+	if err != nil {
+		return errors.Wrapf(err, "getting CRD '%s'", crd.GetName())
+	}
+	if crdUpToDate(crd, current) {
 		return nil
 	}
+	logrus.Infof("Upgrading CRD '%s'", crd.GetName())
+	current.Object["spec"] = crd.Object["spec"]
+	err = c.Update(context.TODO(), current)
+	return errors.Wrapf(err, "updating CRD '%s'", crd.GetName())
+}
 
-	crd := &v1beta1.CustomResourceDefinition{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
-		},
-		Spec: v1beta1.CustomResourceDefinitionSpec{
-			Group:   jenkinsio.GroupName,
-			Version: jenkinsio.Version,
-			Scope:   v1beta1.NamespaceScoped,
-			Names:   *names,
-		},
+// crdUpToDate checks if the registered CRD matches the desired CRD, the fields which are not set in the
+// desired CRD are ignored. The unknown fields are pruned when preserveUnknownFields is omitted.
+func crdUpToDate(desired *unstructured.Unstructured, current *unstructured.Unstructured) bool {
+	preserveUnknownFields, _, _ := unstructured.NestedBool(current.Object, "spec", "preserveUnknownFields")
+	if preserveUnknownFields {
+		return false
+	}
+	spec := map[string]interface{}{}
+	for k, v := range desired.Object["spec"].(map[string]interface{}) {
+		if k != "preserveUnknownFields" {
+			spec[k] = v
+		}
 	}
-	_, err = apiClient.ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd)
-	return err
+	return equality.Semantic.DeepDerivative(spec, current.Object["spec"])
 }
@@ -0,0 +1,57 @@
+package kubernetes
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPISchema(t *testing.T) {
+	schema := openAPISchema(reflect.TypeOf(v1.SSOSpec{}))
+	properties := schema["properties"].(map[string]interface{})
+
+	assert.Equal(t, "object", schema["type"])
+	assert.NotContains(t, schema, "required", "all the spec fields are optional")
+	assert.Equal(t, map[string]interface{}{"type": "string"}, properties["oidcIssuerUrl"])
+	assert.Equal(t, map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": map[string]interface{}{"type": "string"},
+	}, properties["ingressAnnotations"])
+	assert.Equal(t, map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"service": map[string]interface{}{"type": "string"},
+				"port":    map[string]interface{}{"x-kubernetes-int-or-string": true},
+				"path":    map[string]interface{}{"type": "string"},
+			},
+			"required": []interface{}{"service"},
+		},
+	}, properties["upstreams"])
+
+	status := openAPISchema(reflect.TypeOf(v1.SSOStatus{}))["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "string", "format": "date-time"}, status["cookieSecretRotated"])
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, status["observedGeneration"])
+}
+
+func TestCRDUpToDate(t *testing.T) {
+	desired := ssoCRD()
+
+	current := ssoCRD()
+	spec := current.Object["spec"].(map[string]interface{})
+	delete(spec, "preserveUnknownFields")
+	spec["conversion"] = map[string]interface{}{"strategy": "None"}
+	assert.True(t, crdUpToDate(desired, current), "fields defaulted by the API server should be ignored")
+
+	legacy := ssoCRD()
+	legacy.Object["spec"].(map[string]interface{})["preserveUnknownFields"] = true
+	assert.False(t, crdUpToDate(desired, legacy), "a CRD created with apiextensions.k8s.io/v1beta1 should be upgraded")
+
+	schemaless := ssoCRD()
+	versions := schemaless.Object["spec"].(map[string]interface{})["versions"].([]interface{})
+	delete(versions[0].(map[string]interface{}), "schema")
+	assert.False(t, crdUpToDate(desired, schemaless), "a CRD without schema should be upgraded")
+}
@@ -0,0 +1,37 @@
+package kubernetes
+
+import (
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/client/clientset/versioned/scheme"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// NewEventRecorder creates a recorder which emits the events of the given component, the events are written
+// in the namespace of the object they refer to
+func NewEventRecorder(component string) (record.EventRecorder, error) {
+	client, err := GetClientset()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting k8s client")
+	}
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartLogging(logrus.Debugf)
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	return broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component}), nil
+}
+
+// SSOReference returns the reference of the SSO which the events are attached to. The SSOs read from the
+// informer cache have neither a kind nor a self link, which the recorder needs to build the reference itself.
+func SSOReference(sso *v1.SSO) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion:      v1.SchemeGroupVersion.String(),
+		Kind:            v1.SSOKind,
+		Namespace:       sso.GetNamespace(),
+		Name:            sso.GetName(),
+		UID:             sso.GetUID(),
+		ResourceVersion: sso.GetResourceVersion(),
+	}
+}
@@ -0,0 +1,26 @@
+package kubernetes
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/client/clientset/versioned/scheme"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/reference"
+)
+
+func TestSSOReference(t *testing.T) {
+	// The SSOs read from the informer cache have no type meta and no self link
+	sso := &v1.SSO{ObjectMeta: metav1.ObjectMeta{Namespace: "jx", Name: "test", UID: "1234", ResourceVersion: "42"}}
+
+	ref, err := reference.GetReference(scheme.Scheme, SSOReference(sso))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "SSO", ref.Kind)
+	assert.Equal(t, "jenkins.io/v1", ref.APIVersion)
+	assert.Equal(t, "jx", ref.Namespace)
+	assert.Equal(t, "test", ref.Name)
+	assert.Equal(t, "1234", string(ref.UID))
+	assert.Equal(t, "42", ref.ResourceVersion)
+}
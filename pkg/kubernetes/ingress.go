@@ -1,6 +1,7 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/pkg/errors"
@@ -14,7 +15,7 @@ func FindIngressHosts(name string, namespace string) ([]string, error) {
 		return nil, errors.Wrap(err, "getting k8s client")
 	}
 
-	ingresses, err := k8sClient.Extensions().Ingresses(namespace).List(metav1.ListOptions{})
+	ingresses, err := k8sClient.ExtensionsV1beta1().Ingresses(namespace).List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return nil, errors.Wrap(err, "listing ingress resources")
 	}
@@ -0,0 +1,383 @@
+// Package kubetest provides an in process Kubernetes API server backed by the fake Kubernetes and jenkins
+// clientsets. The operator builds its clients from the kubeconfig and keeps a process wide controller-runtime
+// client, so the fake clientsets are served over HTTP instead of being injected.
+package kubetest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	jenkinsfake "github.com/jenkins-x/sso-operator/pkg/client/clientset/versioned/fake"
+	jenkinsscheme "github.com/jenkins-x/sso-operator/pkg/client/clientset/versioned/scheme"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const jenkinsGroup = "jenkins.io"
+
+// resource is an API resource served by the fake server
+type resource struct {
+	kind       schema.GroupVersionKind
+	name       string
+	namespaced bool
+}
+
+// resources are the API resources used by the operator
+var resources = []resource{
+	{kind: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, name: "configmaps", namespaced: true},
+	{kind: schema.GroupVersionKind{Version: "v1", Kind: "Endpoints"}, name: "endpoints", namespaced: true},
+	{kind: schema.GroupVersionKind{Version: "v1", Kind: "Event"}, name: "events", namespaced: true},
+	{kind: schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, name: "namespaces"},
+	{kind: schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, name: "pods", namespaced: true},
+	{kind: schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, name: "secrets", namespaced: true},
+	{kind: schema.GroupVersionKind{Version: "v1", Kind: "Service"}, name: "services", namespaced: true},
+	{kind: schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"}, name: "serviceaccounts", namespaced: true},
+	{kind: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, name: "deployments", namespaced: true},
+	{kind: schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, name: "jobs", namespaced: true},
+	{kind: schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}, name: "ingresses", namespaced: true},
+	{kind: schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, name: "ingresses", namespaced: true},
+	{kind: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, name: "clusterroles"},
+	{kind: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"}, name: "clusterrolebindings"},
+	{kind: schema.GroupVersionKind{Group: jenkinsGroup, Version: "v1", Kind: "SSO"}, name: "ssos", namespaced: true},
+}
+
+// Server serves the objects of the fake clientsets with the Kubernetes REST API, the reactors of the fake
+// clientsets can be used to inject failures
+type Server struct {
+	server *httptest.Server
+	codecs serializer.CodecFactory
+	dir    string
+
+	mu      sync.Mutex
+	kube    *fake.Clientset
+	jenkins *jenkinsfake.Clientset
+}
+
+// NewServer starts a fake API server with empty clientsets
+func NewServer() *Server {
+	s := runtime.NewScheme()
+	scheme.AddToScheme(s)
+	jenkinsscheme.AddToScheme(s)
+	server := &Server{
+		codecs:  serializer.NewCodecFactory(s),
+		kube:    fake.NewSimpleClientset(),
+		jenkins: jenkinsfake.NewSimpleClientset(),
+	}
+	server.server = httptest.NewServer(http.HandlerFunc(server.serve))
+	return server
+}
+
+// Close stops the server
+func (s *Server) Close() {
+	s.server.Close()
+	if s.dir != "" {
+		_ = os.RemoveAll(s.dir)
+	}
+}
+
+// Config returns the client configuration of the server
+func (s *Server) Config() *rest.Config {
+	return &rest.Config{Host: s.server.URL}
+}
+
+// Install points the kubeconfig of the process to the server, all the clients built from the kubeconfig use the
+// server afterwards. The controller-runtime client is built once per process so a test binary should install a
+// single server and replace its clientsets with Reset.
+func (s *Server) Install() error {
+	dir, err := ioutil.TempDir("", "kubetest")
+	if err != nil {
+		return errors.Wrap(err, "creating the kubeconfig directory")
+	}
+	s.dir = dir
+	config := clientcmdapi.NewConfig()
+	config.Clusters["kubetest"] = &clientcmdapi.Cluster{Server: s.server.URL}
+	config.AuthInfos["kubetest"] = &clientcmdapi.AuthInfo{}
+	config.Contexts["kubetest"] = &clientcmdapi.Context{Cluster: "kubetest", AuthInfo: "kubetest"}
+	config.CurrentContext = "kubetest"
+	path := filepath.Join(dir, "kubeconfig")
+	err = clientcmd.WriteToFile(*config, path)
+	if err != nil {
+		return errors.Wrap(err, "writing the kubeconfig")
+	}
+	return os.Setenv(clientcmd.RecommendedConfigPathEnvVar, path)
+}
+
+// Reset replaces the clientsets which store the objects of the server
+func (s *Server) Reset(kube *fake.Clientset, jenkins *jenkinsfake.Clientset) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kube = kube
+	s.jenkins = jenkins
+}
+
+// fakeFor returns the fake clientset which stores the resources of the group
+func (s *Server) fakeFor(group string) *k8stesting.Fake {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if group == jenkinsGroup {
+		return &s.jenkins.Fake
+	}
+	return &s.kube.Fake
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/api":
+		s.write(w, http.StatusOK, &metav1.APIVersions{
+			TypeMeta: metav1.TypeMeta{Kind: "APIVersions"},
+			Versions: []string{"v1"},
+		})
+	case r.URL.Path == "/apis":
+		s.write(w, http.StatusOK, groupList())
+	case segments[0] == "api" && len(segments) == 2:
+		s.write(w, http.StatusOK, resourceList(schema.GroupVersion{Version: segments[1]}))
+	case segments[0] == "apis" && len(segments) == 3:
+		s.write(w, http.StatusOK, resourceList(schema.GroupVersion{Group: segments[1], Version: segments[2]}))
+	case segments[0] == "api" && len(segments) > 2:
+		s.serveResource(w, r, schema.GroupVersion{Version: segments[1]}, segments[2:])
+	case segments[0] == "apis" && len(segments) > 3:
+		s.serveResource(w, r, schema.GroupVersion{Group: segments[1], Version: segments[2]}, segments[3:])
+	default:
+		s.writeError(w, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+	}
+}
+
+// serveResource applies the request to the fake clientset as an action, the path is relative to the
+// group version
+func (s *Server) serveResource(w http.ResponseWriter, r *http.Request, gv schema.GroupVersion, path []string) {
+	namespace := ""
+	if len(path) > 2 && path[0] == "namespaces" {
+		namespace = path[1]
+		path = path[2:]
+	}
+	res, ok := findResource(gv, path[0])
+	if !ok {
+		s.writeError(w, apierrors.NewNotFound(gv.WithResource(path[0]).GroupResource(), ""))
+		return
+	}
+	gvr := gv.WithResource(res.name)
+	name := ""
+	if len(path) > 1 {
+		name = path[1]
+	}
+	subresource := ""
+	if len(path) > 2 {
+		subresource = path[2]
+	}
+	fake := s.fakeFor(gv.Group)
+
+	switch {
+	case r.Method == http.MethodGet && name != "":
+		obj, err := fake.Invokes(k8stesting.NewGetAction(gvr, namespace, name), nil)
+		s.writeObject(w, http.StatusOK, res.kind, obj, err)
+	case r.Method == http.MethodGet && r.URL.Query().Get("watch") != "":
+		s.writeError(w, apierrors.NewMethodNotSupported(gvr.GroupResource(), "watch"))
+	case r.Method == http.MethodGet:
+		opts := metav1.ListOptions{LabelSelector: r.URL.Query().Get("labelSelector")}
+		obj, err := fake.Invokes(k8stesting.NewListAction(gvr, res.kind, namespace, opts), nil)
+		if err == nil {
+			err = filterList(obj, opts.LabelSelector)
+		}
+		listKind := res.kind
+		listKind.Kind += "List"
+		s.writeObject(w, http.StatusOK, listKind, obj, err)
+	case r.Method == http.MethodPost:
+		obj, err := s.decode(r)
+		if err == nil {
+			obj, err = fake.Invokes(k8stesting.NewCreateAction(gvr, namespace, obj), nil)
+		}
+		s.writeObject(w, http.StatusCreated, res.kind, obj, err)
+	case r.Method == http.MethodPut:
+		obj, err := s.decode(r)
+		if err == nil {
+			action := k8stesting.NewUpdateAction(gvr, namespace, obj)
+			if subresource != "" {
+				action = k8stesting.NewUpdateSubresourceAction(gvr, subresource, namespace, obj)
+			}
+			obj, err = fake.Invokes(action, nil)
+		}
+		s.writeObject(w, http.StatusOK, res.kind, obj, err)
+	case r.Method == http.MethodDelete:
+		_, err := fake.Invokes(k8stesting.NewDeleteAction(gvr, namespace, name), nil)
+		s.writeObject(w, http.StatusOK, schema.GroupVersionKind{Version: "v1", Kind: "Status"},
+			&metav1.Status{Status: metav1.StatusSuccess}, err)
+	default:
+		s.writeError(w, apierrors.NewMethodNotSupported(gvr.GroupResource(), r.Method))
+	}
+}
+
+// decode decodes the object of the request, the objects of the API types which are not vendored are
+// kept unstructured
+func (s *Server) decode(r *http.Request) (runtime.Object, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	obj, err := runtime.Decode(s.codecs.UniversalDeserializer(), body)
+	if err == nil {
+		mergeStringData(obj)
+		return obj, nil
+	}
+	if !runtime.IsNotRegisteredError(err) {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	u := &unstructured.Unstructured{}
+	err = u.UnmarshalJSON(body)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	return u, nil
+}
+
+// mergeStringData moves the string data of a secret into its data, like the API server does
+func mergeStringData(obj runtime.Object) {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok || len(secret.StringData) == 0 {
+		return
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for key, value := range secret.StringData {
+		secret.Data[key] = []byte(value)
+	}
+	secret.StringData = nil
+}
+
+// writeObject writes the object with its kind, or the error as a status
+func (s *Server) writeObject(w http.ResponseWriter, code int, kind schema.GroupVersionKind, obj runtime.Object, err error) {
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if obj == nil {
+		s.writeError(w, apierrors.NewInternalError(errors.New("no reactor handled the request")))
+		return
+	}
+	obj.GetObjectKind().SetGroupVersionKind(kind)
+	s.write(w, code, obj)
+}
+
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	status, ok := err.(apierrors.APIStatus)
+	if !ok {
+		status = apierrors.NewInternalError(err)
+	}
+	result := status.Status()
+	result.Kind = "Status"
+	result.APIVersion = "v1"
+	s.write(w, int(result.Code), &result)
+}
+
+func (s *Server) write(w http.ResponseWriter, code int, obj interface{}) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
+
+// filterList removes from the list the items which do not match the label selector, the fake clientsets
+// ignore the selectors
+func filterList(list runtime.Object, selector string) error {
+	if selector == "" {
+		return nil
+	}
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	matching := []runtime.Object{}
+	for _, item := range items {
+		accessor, err := meta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		if parsed.Matches(labels.Set(accessor.GetLabels())) {
+			matching = append(matching, item)
+		}
+	}
+	return meta.SetList(list, matching)
+}
+
+func findResource(gv schema.GroupVersion, name string) (resource, bool) {
+	for _, res := range resources {
+		if res.kind.GroupVersion() == gv && res.name == name {
+			return res, true
+		}
+	}
+	return resource{}, false
+}
+
+func groupList() *metav1.APIGroupList {
+	list := &metav1.APIGroupList{TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"}}
+	for _, res := range resources {
+		gv := res.kind.GroupVersion()
+		if gv.Group == "" {
+			continue
+		}
+		found := false
+		for _, group := range list.Groups {
+			if group.Name == gv.Group {
+				found = true
+			}
+		}
+		if found {
+			continue
+		}
+		version := metav1.GroupVersionForDiscovery{GroupVersion: gv.String(), Version: gv.Version}
+		list.Groups = append(list.Groups, metav1.APIGroup{
+			Name:             gv.Group,
+			Versions:         []metav1.GroupVersionForDiscovery{version},
+			PreferredVersion: version,
+		})
+	}
+	return list
+}
+
+func resourceList(gv schema.GroupVersion) *metav1.APIResourceList {
+	list := &metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: gv.String(),
+	}
+	for _, res := range resources {
+		if res.kind.GroupVersion() != gv {
+			continue
+		}
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:       res.name,
+			Namespaced: res.namespaced,
+			Kind:       res.kind.Kind,
+			Verbs:      metav1.Verbs{"create", "delete", "get", "list", "update"},
+		})
+	}
+	return list
+}
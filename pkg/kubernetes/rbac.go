@@ -1,6 +1,7 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/pkg/errors"
@@ -23,7 +24,7 @@ func EnsureClusterRoleBinding(clusterRoleName string, namespace string) (string,
 		return "", errors.Wrap(err, "getting k8s client")
 	}
 
-	roleBindingList, err := k8sClient.RbacV1().ClusterRoleBindings().List(metav1.ListOptions{})
+	roleBindingList, err := k8sClient.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return "", errors.Wrap(err, "listing cluster role bindings")
 	}
@@ -49,7 +50,7 @@ func EnsureClusterRoleBinding(clusterRoleName string, namespace string) (string,
 			}
 			roleBinding.Subjects = append(roleBinding.Subjects, subj)
 
-			_, err = k8sClient.RbacV1().ClusterRoleBindings().Update(&roleBinding) // #nosec
+			_, err = k8sClient.RbacV1().ClusterRoleBindings().Update(context.TODO(), &roleBinding, metav1.UpdateOptions{}) // #nosec
 			if err != nil {
 				return "", errors.Wrapf(err, "adding service account '%s' to cluster role binding '%s'", saName, roleBinding.Name)
 			}
@@ -67,7 +68,7 @@ func CreateServiceAccount(name string, namespace string) (string, error) {
 		return "", errors.Wrap(err, "getting k8s client")
 	}
 
-	sa, err := k8sClient.CoreV1().ServiceAccounts(namespace).Get(name, metav1.GetOptions{})
+	sa, err := k8sClient.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 	// If a service account already exists just re-use it
 	if err == nil {
 		return sa.Name, nil
@@ -84,7 +85,7 @@ func CreateServiceAccount(name string, namespace string) (string, error) {
 		},
 	}
 
-	sa, err = k8sClient.CoreV1().ServiceAccounts(namespace).Create(sa)
+	sa, err = k8sClient.CoreV1().ServiceAccounts(namespace).Create(context.TODO(), sa, metav1.CreateOptions{})
 	if err != nil {
 		return "", errors.Wrapf(err, "creating service account '%s'", sa)
 	}
@@ -0,0 +1,83 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DeploymentRolledOut checks if the latest generation of the deployment is rolled out to all its replicas
+func DeploymentRolledOut(c kubernetes.Interface, namespace, name string) (bool, error) {
+	d, err := c.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	return deploymentRolledOut(d), nil
+}
+
+func deploymentRolledOut(d *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return d.Generation <= d.Status.ObservedGeneration &&
+		d.Status.UpdatedReplicas == replicas &&
+		d.Status.Replicas == replicas &&
+		d.Status.AvailableReplicas == replicas
+}
+
+// PodsWithLabelRunning checks if at least one pod matches the label and all matching pods are running and ready
+func PodsWithLabelRunning(c kubernetes.Interface, namespace string, label labels.Selector) (bool, error) {
+	pods, err := c.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: label.String()})
+	if err != nil {
+		return false, err
+	}
+	return podsRunning(pods.Items), nil
+}
+
+func podsRunning(pods []v1.Pod) bool {
+	if len(pods) == 0 {
+		return false
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase != v1.PodRunning {
+			return false
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if !cs.Ready {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// JobComplete checks if the job completed, an error is returned when the job failed
+func JobComplete(c kubernetes.Interface, namespace, name string) (bool, error) {
+	job, err := c.BatchV1().Jobs(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	return jobComplete(job)
+}
+
+func jobComplete(job *batchv1.Job) (bool, error) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		case batchv1.JobFailed:
+			return false, fmt.Errorf("job failed: %s", condition.Message)
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,65 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeploymentRolledOut(t *testing.T) {
+	replicas := int32(2)
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Generation: 3},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 3,
+			Replicas:           2,
+			UpdatedReplicas:    2,
+			AvailableReplicas:  2,
+		},
+	}
+	assert.True(t, deploymentRolledOut(d))
+
+	d.Generation = 4
+	assert.False(t, deploymentRolledOut(d), "a new generation should not be observed yet")
+
+	d.Generation = 3
+	d.Status.Replicas = 3
+	d.Status.UpdatedReplicas = 1
+	assert.False(t, deploymentRolledOut(d), "the old replicas should not be replaced yet")
+}
+
+func TestPodsRunning(t *testing.T) {
+	ready := v1.Pod{Status: v1.PodStatus{
+		Phase:             v1.PodRunning,
+		ContainerStatuses: []v1.ContainerStatus{{Ready: true}},
+	}}
+	starting := v1.Pod{Status: v1.PodStatus{
+		Phase:             v1.PodRunning,
+		ContainerStatuses: []v1.ContainerStatus{{Ready: false}},
+	}}
+
+	assert.True(t, podsRunning([]v1.Pod{ready}))
+	assert.False(t, podsRunning([]v1.Pod{ready, starting}))
+	assert.False(t, podsRunning(nil), "at least one pod should exist")
+}
+
+func TestJobComplete(t *testing.T) {
+	job := &batchv1.Job{}
+	complete, err := jobComplete(job)
+	assert.NoError(t, err)
+	assert.False(t, complete)
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+	complete, err = jobComplete(job)
+	assert.NoError(t, err)
+	assert.True(t, complete)
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+	_, err = jobComplete(job)
+	assert.Error(t, err)
+}
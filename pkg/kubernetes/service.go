@@ -0,0 +1,17 @@
+package kubernetes
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetService gets the service with the given name
+func GetService(namespace string, name string) (*v1.Service, error) {
+	k8sClient, err := GetClientset()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting k8s client")
+	}
+	return k8sClient.CoreV1().Services(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
@@ -1,6 +1,7 @@
 package kubernetes
 
 import (
+	"context"
 	"github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -13,7 +14,7 @@ func IsSSOInitialized(sso *v1.SSO) (bool, error) {
 		return false, errors.Wrap(err, "getting Jenkins client")
 	}
 
-	ssos, err := client.JenkinsV1().SSOs(sso.GetNamespace()).List(metav1.ListOptions{})
+	ssos, err := client.JenkinsV1().SSOs(sso.GetNamespace()).List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return false, errors.Wrap(err, "listing SSO resources")
 	}
@@ -26,3 +27,21 @@ func IsSSOInitialized(sso *v1.SSO) (bool, error) {
 
 	return false, nil
 }
+
+// UpdateSSOStatus updates the status subresource of the given SSO
+func UpdateSSOStatus(sso *v1.SSO) (*v1.SSO, error) {
+	client, err := GetJenkinsClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting Jenkins client")
+	}
+	return client.JenkinsV1().SSOs(sso.GetNamespace()).UpdateStatus(context.TODO(), sso, metav1.UpdateOptions{})
+}
+
+// UpdateSSO updates the given SSO
+func UpdateSSO(sso *v1.SSO) (*v1.SSO, error) {
+	client, err := GetJenkinsClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting Jenkins client")
+	}
+	return client.JenkinsV1().SSOs(sso.GetNamespace()).Update(context.TODO(), sso, metav1.UpdateOptions{})
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package kubernetes
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -33,15 +34,14 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	watchtools "k8s.io/client-go/tools/watch"
 )
 
 // WaitForPodReady waits for given POD to become ready
 func WaitForPodReady(pods corev1.PodInterface, podName string) error {
 	logrus.Infof("Waiting for %s to be scheduled", podName)
 	err := wait.PollImmediate(time.Second, time.Minute*2, func() (bool, error) {
-		_, err := pods.Get(podName, meta_v1.GetOptions{
-			IncludeUninitialized: true,
-		})
+		_, err := pods.Get(context.TODO(), podName, meta_v1.GetOptions{})
 		if err != nil {
 			logrus.Infof("Getting pod: %v.", err)
 			return false, nil
@@ -54,9 +54,7 @@ func WaitForPodReady(pods corev1.PodInterface, podName string) error {
 
 	logrus.Infof("Waiting for %s to be ready.", podName)
 	return wait.PollImmediate(time.Second, time.Minute*10, func() (bool, error) {
-		pod, err := pods.Get(podName, meta_v1.GetOptions{
-			IncludeUninitialized: true,
-		})
+		pod, err := pods.Get(context.TODO(), podName, meta_v1.GetOptions{})
 		if err != nil {
 			return false, fmt.Errorf("not found: %s", podName)
 		}
@@ -82,9 +80,7 @@ func WaitForPodReady(pods corev1.PodInterface, podName string) error {
 func WaitForPodComplete(pods corev1.PodInterface, podName string, timeout time.Duration) error {
 	logrus.Infof("Waiting for %s to be ready", podName)
 	return wait.PollImmediate(time.Millisecond*500, timeout, func() (bool, error) {
-		pod, err := pods.Get(podName, meta_v1.GetOptions{
-			IncludeUninitialized: true,
-		})
+		pod, err := pods.Get(context.TODO(), podName, meta_v1.GetOptions{})
 		if err != nil {
 			logrus.Infof("Getting pod %s.", err)
 			return false, nil
@@ -108,7 +104,7 @@ func WaitForPodsWithLabelRunning(c kubernetes.Interface, namespace string, label
 	lastKnownPodNumber := -1
 	return wait.Poll(time.Second*3, timeout, func() (bool, error) {
 		listOpts := meta_v1.ListOptions{LabelSelector: label.String()}
-		pods, err := c.CoreV1().Pods(namespace).List(listOpts)
+		pods, err := c.CoreV1().Pods(namespace).List(context.TODO(), listOpts)
 		if err != nil {
 			logrus.Infof("Error getting Pods with label selector %q [%v]\n", label.String(), err)
 			return false, nil
@@ -147,11 +143,13 @@ func WaitForDeploymentToStabilize(c kubernetes.Interface, namespace, name string
 		"metadata.name":      name,
 		"metadata.namespace": namespace,
 	}.AsSelector().String()}
-	w, err := c.AppsV1().Deployments(namespace).Watch(options)
+	w, err := c.AppsV1().Deployments(namespace).Watch(context.TODO(), options)
 	if err != nil {
 		return err
 	}
-	_, err = watch.Until(timeout, w, func(event watch.Event) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err = watchtools.UntilWithoutRetry(ctx, w, func(event watch.Event) (bool, error) {
 		switch event.Type {
 		case watch.Deleted:
 			return false, apierrs.NewNotFound(schema.GroupResource{Resource: "deployments"}, "")
@@ -175,7 +173,7 @@ func WaitForDeploymentToStabilize(c kubernetes.Interface, namespace, name string
 // WaitForService waits until the service appears (exist == true), or disappears (exist == false)
 func WaitForService(c kubernetes.Interface, namespace, name string, exist bool, interval, timeout time.Duration) error {
 	err := wait.PollImmediate(interval, timeout, func() (bool, error) {
-		_, err := c.CoreV1().Services(namespace).Get(name, meta_v1.GetOptions{})
+		_, err := c.CoreV1().Services(namespace).Get(context.TODO(), name, meta_v1.GetOptions{})
 		switch {
 		case err == nil:
 			logrus.Infof("Service %s in namespace %s found.", name, namespace)
@@ -202,7 +200,7 @@ func WaitForService(c kubernetes.Interface, namespace, name string, exist bool,
 func WaitForServiceEndpointsNum(c kubernetes.Interface, namespace, serviceName string, expectNum int, interval, timeout time.Duration) error {
 	return wait.Poll(interval, timeout, func() (bool, error) {
 		logrus.Infof("Waiting for amount of service:%s endpoints to be %d.", serviceName, expectNum)
-		list, err := c.CoreV1().Endpoints(namespace).List(meta_v1.ListOptions{})
+		list, err := c.CoreV1().Endpoints(namespace).List(context.TODO(), meta_v1.ListOptions{})
 		if err != nil {
 			return false, err
 		}
@@ -232,7 +230,7 @@ func IsRetryableAPIError(err error) bool {
 // WaitForJobComplete wait for a job to complete
 func WaitForJobComplete(c kubernetes.Interface, namespace, name string, interval, timeout time.Duration) error {
 	err := wait.PollImmediate(interval, timeout, func() (bool, error) {
-		job, err := c.BatchV1().Jobs(namespace).Get(name, meta_v1.GetOptions{})
+		job, err := c.BatchV1().Jobs(namespace).Get(context.TODO(), name, meta_v1.GetOptions{})
 		switch {
 		case err == nil:
 			conditions := job.Status.Conditions
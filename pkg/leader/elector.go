@@ -0,0 +1,78 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/leaderelection"
+)
+
+// Config configures the leader election between the replicas of the operator
+type Config struct {
+	// Namespace of the lease
+	Namespace string
+	// Name of the lease
+	Name string
+	// Identity of this replica, usually the pod name
+	Identity string
+	// LeaseDuration is the time a follower waits before taking over a lease which is not renewed
+	LeaseDuration time.Duration
+	// RenewDeadline is the time the leader retries to renew the lease before giving up the leadership
+	RenewDeadline time.Duration
+	// RetryPeriod is the time between two attempts to acquire or renew the lease
+	RetryPeriod time.Duration
+}
+
+// Elector runs the operator only in the replica which holds the lease
+type Elector struct {
+	config  Config
+	leading int32
+}
+
+// NewElector creates an elector for the given lease
+func NewElector(config Config) *Elector {
+	return &Elector{config: config}
+}
+
+// IsLeader checks if this replica currently holds the lease
+func (e *Elector) IsLeader() bool {
+	return atomic.LoadInt32(&e.leading) == 1
+}
+
+// Run blocks until the lease is acquired, then calls run with a context which is cancelled when the lease is lost.
+// It returns once the lease is lost, in which case the replica should exit since the stopped work cannot be resumed.
+func (e *Elector) Run(ctx context.Context, run func(ctx context.Context)) error {
+	lock, err := newLeaseLock(e.config.Namespace, e.config.Name, e.config.Identity)
+	if err != nil {
+		return err
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: e.config.LeaseDuration,
+		RenewDeadline: e.config.RenewDeadline,
+		RetryPeriod:   e.config.RetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				atomic.StoreInt32(&e.leading, 1)
+				logrus.Infof("%s started leading with lease %s", e.config.Identity, lock.Describe())
+				run(leaderCtx)
+			},
+			OnStoppedLeading: func() {
+				atomic.StoreInt32(&e.leading, 0)
+				logrus.Warnf("%s stopped leading with lease %s", e.config.Identity, lock.Describe())
+			},
+			OnNewLeader: func(identity string) {
+				logrus.Infof("%s is the leader of lease %s", identity, lock.Describe())
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "configuring the leader election")
+	}
+	logrus.Infof("%s waiting to acquire lease %s", e.config.Identity, lock.Describe())
+	elector.Run(ctx)
+	return errors.Errorf("lease %s lost", lock.Describe())
+}
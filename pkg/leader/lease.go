@@ -0,0 +1,26 @@
+package leader
+
+import (
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	rl "k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// newLeaseLock creates a resource lock backed by a coordination.k8s.io/v1 lease
+func newLeaseLock(namespace string, name string, identity string) (*rl.LeaseLock, error) {
+	client, err := kubernetes.GetClientset()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting k8s client")
+	}
+	return &rl.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Client: client.CoordinationV1(),
+		LockConfig: rl.ResourceLockConfig{
+			Identity: identity,
+		},
+	}, nil
+}
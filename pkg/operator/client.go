@@ -0,0 +1,179 @@
+package operator
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/jenkins-x/sso-operator/pkg/proxy"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	clientSecretSuffix     = "oidc-client"
+	clientIDKey            = "clientId"
+	clientSecretKey        = "clientSecret"
+	pendingClientIDKey     = "pendingClientId"
+	pendingClientSecretKey = "pendingClientSecret"
+
+	// rotateClientSecretAnnotation requests a rotation of the OIDC client secret when its value is a
+	// RFC3339 time after the last rotation
+	rotateClientSecretAnnotation = "jenkins.io/rotate-client-secret"
+)
+
+// clientID derives the ID of the OIDC client from the namespace, name and UID of the SSO, a SSO
+// re-created with the same name gets a new client
+func clientID(sso *v1.SSO) string {
+	return deriveClientID(sso, "")
+}
+
+// rotatedClientID derives the ID of the OIDC client which replaces the current client of the SSO,
+// since dex does not allow to change the secret of an existing client
+func rotatedClientID(sso *v1.SSO, rotation time.Time) string {
+	return deriveClientID(sso, rotation.UTC().Format(time.RFC3339Nano))
+}
+
+func deriveClientID(sso *v1.SSO, salt string) string {
+	seed := fmt.Sprintf("%s/%s/%s", sso.GetNamespace(), sso.GetName(), sso.GetUID())
+	if salt != "" {
+		seed += "/" + salt
+	}
+	hash := sha256.Sum256([]byte(seed))
+	return fmt.Sprintf("%s-%s-%x", sso.GetNamespace(), sso.GetName(), hash[:6])
+}
+
+func clientSecretName(sso *v1.SSO) string {
+	return fmt.Sprintf("%s-%s", sso.GetName(), clientSecretSuffix)
+}
+
+// ensureClientSecret returns the secret of the OIDC client stored in a Kubernetes secret, and
+// generates it if it was not stored yet. The second value is true when a new secret was generated.
+func ensureClientSecret(sso *v1.SSO, clientID string) (string, bool, error) {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return "", false, errors.Wrap(err, "getting k8s client")
+	}
+	secrets := k8sClient.CoreV1().Secrets(sso.GetNamespace())
+	name := clientSecretName(sso)
+	secret, err := secrets.Get(context.TODO(), name, metav1.GetOptions{})
+	exists := err == nil
+	if exists && string(secret.Data[clientIDKey]) == clientID && len(secret.Data[clientSecretKey]) > 0 {
+		return string(secret.Data[clientSecretKey]), false, nil
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", false, errors.Wrapf(err, "getting the OIDC client secret '%s'", name)
+	}
+
+	clientSecret, err := proxy.GenerateClientSecret()
+	if err != nil {
+		return "", false, errors.Wrap(err, "generating the OIDC client secret")
+	}
+	data := map[string][]byte{
+		clientIDKey:     []byte(clientID),
+		clientSecretKey: []byte(clientSecret),
+	}
+	if exists {
+		secret.Data = data
+		_, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+		if err != nil {
+			return "", false, errors.Wrapf(err, "updating the OIDC client secret '%s'", name)
+		}
+		return clientSecret, true, nil
+	}
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       sso.GetNamespace(),
+			Labels:          map[string]string{"sso": sso.GetName()},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(sso, v1.SchemeGroupVersion.WithKind(v1.SSOKind))},
+		},
+		Data: data,
+		Type: corev1.SecretTypeOpaque,
+	}
+	_, err = secrets.Create(context.TODO(), secret, metav1.CreateOptions{})
+	if err != nil {
+		return "", false, errors.Wrapf(err, "creating the OIDC client secret '%s'", name)
+	}
+	return clientSecret, true, nil
+}
+
+// clientRotationDue checks if the secret of the OIDC client has to be rotated, either because a rotation was
+// requested with an annotation or because a previous rotation did not complete
+func clientRotationDue(sso *v1.SSO) (bool, error) {
+	if sso.Status.PendingClientID != "" {
+		return true, nil
+	}
+	lastRotation := sso.GetCreationTimestamp().Time
+	if sso.Status.ClientSecretRotated != nil {
+		lastRotation = sso.Status.ClientSecretRotated.Time
+	}
+	return rotationRequested(sso, rotateClientSecretAnnotation, lastRotation)
+}
+
+// ensurePendingClient returns the ID and secret of the OIDC client which replaces the current client during a
+// rotation, a new client ID and secret are generated and stored when no rotation is in progress
+func ensurePendingClient(sso *v1.SSO, now time.Time) (string, string, error) {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return "", "", errors.Wrap(err, "getting k8s client")
+	}
+	secrets := k8sClient.CoreV1().Secrets(sso.GetNamespace())
+	name := clientSecretName(sso)
+	secret, err := secrets.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", "", errors.Wrapf(err, "getting the OIDC client secret '%s'", name)
+	}
+	id := string(secret.Data[pendingClientIDKey])
+	clientSecret := string(secret.Data[pendingClientSecretKey])
+	if id != "" && clientSecret != "" {
+		return id, clientSecret, nil
+	}
+
+	id = rotatedClientID(sso, now)
+	clientSecret, err = proxy.GenerateClientSecret()
+	if err != nil {
+		return "", "", errors.Wrap(err, "generating the OIDC client secret")
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[pendingClientIDKey] = []byte(id)
+	secret.Data[pendingClientSecretKey] = []byte(clientSecret)
+	_, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+	if err != nil {
+		return "", "", errors.Wrapf(err, "updating the OIDC client secret '%s'", name)
+	}
+	return id, clientSecret, nil
+}
+
+// promotePendingClient replaces in the Kubernetes secret the current OIDC client with the pending client
+func promotePendingClient(sso *v1.SSO) error {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+	secrets := k8sClient.CoreV1().Secrets(sso.GetNamespace())
+	name := clientSecretName(sso)
+	secret, err := secrets.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "getting the OIDC client secret '%s'", name)
+	}
+	if len(secret.Data[pendingClientIDKey]) == 0 {
+		return nil
+	}
+	secret.Data = map[string][]byte{
+		clientIDKey:     secret.Data[pendingClientIDKey],
+		clientSecretKey: secret.Data[pendingClientSecretKey],
+	}
+	_, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "updating the OIDC client secret '%s'", name)
+	}
+	return nil
+}
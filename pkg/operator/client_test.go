@@ -0,0 +1,75 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClientIDIsDeterministic(t *testing.T) {
+	sso := &v1.SSO{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sso-golang-http",
+			Namespace: "jx-staging",
+			UID:       "0c6bd87e-9b4e-11e8-a1a9-42010a840052",
+		},
+	}
+	recreated := sso.DeepCopy()
+	recreated.UID = "5a3c29de-9b4f-11e8-a1a9-42010a840052"
+
+	id := clientID(sso)
+
+	assert.Equal(t, id, clientID(sso.DeepCopy()), "the same SSO should always get the same client ID")
+	assert.NotEqual(t, id, clientID(recreated), "a re-created SSO should get a new client ID")
+	assert.Contains(t, id, "jx-staging-sso-golang-http-")
+}
+
+func TestRotatedClientID(t *testing.T) {
+	sso := &v1.SSO{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sso-golang-http",
+			Namespace: "jx-staging",
+			UID:       "0c6bd87e-9b4e-11e8-a1a9-42010a840052",
+		},
+	}
+	now := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	id := rotatedClientID(sso, now)
+
+	assert.NotEqual(t, clientID(sso), id, "a rotated client should get a new client ID")
+	assert.Equal(t, id, rotatedClientID(sso, now), "the same rotation should always get the same client ID")
+	assert.NotEqual(t, id, rotatedClientID(sso, now.Add(time.Second)))
+	assert.Contains(t, id, "jx-staging-sso-golang-http-")
+}
+
+func TestClientRotationDue(t *testing.T) {
+	created := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	sso := &v1.SSO{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(created),
+		},
+	}
+
+	due, err := clientRotationDue(sso)
+	assert.NoError(t, err)
+	assert.False(t, due, "no rotation should be due without request")
+
+	sso.SetAnnotations(map[string]string{rotateClientSecretAnnotation: created.Add(time.Hour).Format(time.RFC3339)})
+	due, err = clientRotationDue(sso)
+	assert.NoError(t, err)
+	assert.True(t, due, "a rotation requested after the creation should be due")
+
+	rotated := metav1.NewTime(created.Add(2 * time.Hour))
+	sso.Status.ClientSecretRotated = &rotated
+	due, err = clientRotationDue(sso)
+	assert.NoError(t, err)
+	assert.False(t, due, "a request older than the last rotation should be ignored")
+
+	sso.Status.PendingClientID = "jx-staging-sso-golang-http-123456"
+	due, err = clientRotationDue(sso)
+	assert.NoError(t, err)
+	assert.True(t, due, "a rotation in progress should be resumed")
+}
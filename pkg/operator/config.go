@@ -1,6 +1,7 @@
 package operator
 
 import (
+	"context"
 	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
@@ -23,7 +24,7 @@ func getOperatorConfigFromSecret(namespace string) (*operatorConfig, error) {
 		return nil, errors.Wrap(err, "getting k8s client")
 	}
 
-	secret, err := k8sClient.CoreV1().Secrets(namespace).Get(operatorSecretName, metav1.GetOptions{})
+	secret, err := k8sClient.CoreV1().Secrets(namespace).Get(context.TODO(), operatorSecretName, metav1.GetOptions{})
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			return nil, errors.New("operator config secret not found")
@@ -54,7 +55,7 @@ func deleteOperatorConfigSecret(namespace string) error {
 	if err != nil {
 		return errors.Wrap(err, "getting k8s client")
 	}
-	return k8sClient.CoreV1().Secrets(namespace).Delete(operatorSecretName, &metav1.DeleteOptions{})
+	return k8sClient.CoreV1().Secrets(namespace).Delete(context.TODO(), operatorSecretName, metav1.DeleteOptions{})
 }
 
 func storeOperatorConfigInSecret(namespace string, config *operatorConfig) error {
@@ -78,6 +79,6 @@ func storeOperatorConfigInSecret(namespace string, config *operatorConfig) error
 		Type: v1.SecretTypeOpaque,
 	}
 
-	_, err = k8sClient.CoreV1().Secrets(namespace).Create(secret)
+	_, err = k8sClient.CoreV1().Secrets(namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
 	return err
 }
@@ -0,0 +1,74 @@
+package operator
+
+import (
+	"time"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/jenkins-x/sso-operator/pkg/proxy"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// NewManager creates the manager which caches the SSOs and their owned resources in the watched namespace, all
+// the namespaces are watched when it is empty. The metrics are served by the health probes of the operator.
+func NewManager(watchNamespace string, resync time.Duration) (manager.Manager, error) {
+	config, err := kubernetes.GetClientConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting client config for the manager")
+	}
+	return manager.New(config, manager.Options{
+		Scheme:             kubernetes.Scheme,
+		Namespace:          watchNamespace,
+		SyncPeriod:         &resync,
+		MetricsBindAddress: "0",
+	})
+}
+
+// NewController creates the controller which reconciles a SSO when it changes or when one of its owned
+// resources changes, the resources which expose the proxy are only watched when their API is served by the cluster
+func NewController(mgr manager.Manager, reconciler *Handler, workers int) error {
+	c, err := controller.New("sso", mgr, controller.Options{
+		Reconciler:              reconciler,
+		MaxConcurrentReconciles: workers,
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating the SSO controller")
+	}
+	err = c.Watch(&source.Kind{Type: &v1.SSO{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return errors.Wrap(err, "watching the SSOs")
+	}
+
+	owned := []client.Object{&appsv1.Deployment{}, &corev1.Service{}, &corev1.Secret{}}
+	for _, resource := range proxy.ExposerResources() {
+		gvk := schema.FromAPIVersionAndKind(resource.APIVersion, resource.Kind)
+		_, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			logrus.Infof("not watching %s %s: %v", resource.APIVersion, resource.Kind, err)
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		owned = append(owned, obj)
+	}
+	for _, obj := range owned {
+		err = c.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestForOwner{
+			OwnerType:    &v1.SSO{},
+			IsController: true,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "watching the %T owned by the SSOs", obj)
+		}
+	}
+	return nil
+}
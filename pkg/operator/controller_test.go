@@ -0,0 +1,20 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewControllerSkipsUnservedExposers(t *testing.T) {
+	env := newTestEnv(t, testSSO())
+	defer env.close()
+	mgr, err := NewManager(testNamespace, time.Minute)
+	require.NoError(t, err)
+
+	// The fake API server serves the ingresses but neither the HTTPRoutes nor the OpenShift routes
+	err = NewController(mgr, env.handler, 1)
+
+	require.NoError(t, err)
+}
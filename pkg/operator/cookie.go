@@ -0,0 +1,145 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/jenkins-x/sso-operator/pkg/proxy"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	cookieSecretSuffix = "cookie"
+	cookieSecretKey    = "cookieSecret"
+
+	// rotateCookieSecretAnnotation requests a rotation of the cookie secret when its value is a
+	// RFC3339 time after the last rotation
+	rotateCookieSecretAnnotation = "jenkins.io/rotate-cookie-secret"
+)
+
+func cookieSecretName(sso *v1.SSO) string {
+	return fmt.Sprintf("%s-%s", sso.GetName(), cookieSecretSuffix)
+}
+
+// ensureCookieSecret returns the key which signs the cookies of the SSO proxy, and stores a new key when
+// the SSO has none yet. The seed is used as new key when not empty, which allows the SSO created before
+// the keys were per SSO to keep the shared key. The second value is true when a new key was stored.
+func ensureCookieSecret(sso *v1.SSO, seed string) (string, bool, error) {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return "", false, errors.Wrap(err, "getting k8s client")
+	}
+	secrets := k8sClient.CoreV1().Secrets(sso.GetNamespace())
+	name := cookieSecretName(sso)
+	secret, err := secrets.Get(context.TODO(), name, metav1.GetOptions{})
+	if err == nil && len(secret.Data[cookieSecretKey]) > 0 {
+		return string(secret.Data[cookieSecretKey]), false, nil
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", false, errors.Wrapf(err, "getting the cookie secret '%s'", name)
+	}
+	if err == nil {
+		return rotateCookieSecret(sso)
+	}
+
+	cookieSecret := seed
+	if cookieSecret == "" {
+		cookieSecret, err = proxy.GenerateCookieKey()
+		if err != nil {
+			return "", false, errors.Wrap(err, "generating the cookie secret")
+		}
+	}
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       sso.GetNamespace(),
+			Labels:          map[string]string{"sso": sso.GetName()},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(sso, v1.SchemeGroupVersion.WithKind(v1.SSOKind))},
+		},
+		Data: map[string][]byte{
+			cookieSecretKey: []byte(cookieSecret),
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+	_, err = secrets.Create(context.TODO(), secret, metav1.CreateOptions{})
+	if err != nil {
+		return "", false, errors.Wrapf(err, "creating the cookie secret '%s'", name)
+	}
+	return cookieSecret, true, nil
+}
+
+// rotateCookieSecret replaces the cookie key of the SSO with a newly generated key
+func rotateCookieSecret(sso *v1.SSO) (string, bool, error) {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return "", false, errors.Wrap(err, "getting k8s client")
+	}
+	secrets := k8sClient.CoreV1().Secrets(sso.GetNamespace())
+	name := cookieSecretName(sso)
+	secret, err := secrets.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", false, errors.Wrapf(err, "getting the cookie secret '%s'", name)
+	}
+	cookieSecret, err := proxy.GenerateCookieKey()
+	if err != nil {
+		return "", false, errors.Wrap(err, "generating the cookie secret")
+	}
+	secret.Data = map[string][]byte{
+		cookieSecretKey: []byte(cookieSecret),
+	}
+	_, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+	if err != nil {
+		return "", false, errors.Wrapf(err, "updating the cookie secret '%s'", name)
+	}
+	return cookieSecret, true, nil
+}
+
+// cookieRotationDue checks if the cookie secret of the SSO has to be rotated, either because a rotation was
+// requested with an annotation or because the rotation interval elapsed. No rotation happens within the minimum
+// interval after the last one, which leaves the time to the proxy to roll out the current key. oauth2_proxy only
+// accepts the cookies signed with its current key, every rotation logs out all the users of the SSO.
+func cookieRotationDue(sso *v1.SSO, now time.Time, minInterval time.Duration) (bool, string, error) {
+	rotated := sso.Status.CookieSecretRotated
+	if rotated == nil {
+		return false, "", nil
+	}
+	if now.Sub(rotated.Time) < minInterval {
+		return false, "", nil
+	}
+	requested, err := rotationRequested(sso, rotateCookieSecretAnnotation, rotated.Time)
+	if err != nil {
+		return false, "", err
+	}
+	if requested {
+		return true, "requested by annotation", nil
+	}
+	if sso.Spec.CookieSpec.RotationInterval != "" {
+		interval, err := time.ParseDuration(sso.Spec.CookieSpec.RotationInterval)
+		if err != nil {
+			return false, "", errors.Wrap(err, "parsing the cookie rotation interval")
+		}
+		if interval > 0 && now.Sub(rotated.Time) >= interval {
+			return true, fmt.Sprintf("rotation interval of %s elapsed", interval), nil
+		}
+	}
+	return false, "", nil
+}
+
+// rotationRequested checks if the given annotation of the SSO requests a rotation after the last one
+func rotationRequested(sso *v1.SSO, annotation string, lastRotation time.Time) (bool, error) {
+	value, ok := sso.GetAnnotations()[annotation]
+	if !ok || value == "" {
+		return false, nil
+	}
+	requested, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false, errors.Wrapf(err, "parsing the '%s' annotation", annotation)
+	}
+	return requested.After(lastRotation), nil
+}
@@ -0,0 +1,79 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCookieRotationDue(t *testing.T) {
+	now := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	rotated := metav1.NewTime(now.Add(-time.Hour))
+	minInterval := 10 * time.Minute
+
+	tests := map[string]struct {
+		annotation string
+		interval   string
+		rotated    *metav1.Time
+		due        bool
+	}{
+		"never rotated": {
+			interval: "1m",
+		},
+		"nothing requested": {
+			rotated: &rotated,
+		},
+		"interval elapsed": {
+			interval: "30m",
+			rotated:  &rotated,
+			due:      true,
+		},
+		"interval not elapsed": {
+			interval: "2h",
+			rotated:  &rotated,
+		},
+		"requested after last rotation": {
+			annotation: now.Add(-time.Minute).Format(time.RFC3339),
+			rotated:    &rotated,
+			due:        true,
+		},
+		"requested before last rotation": {
+			annotation: now.Add(-2 * time.Hour).Format(time.RFC3339),
+			rotated:    &rotated,
+		},
+		"within minimum interval": {
+			annotation: now.Format(time.RFC3339),
+			interval:   "1m",
+			rotated:    &metav1.Time{Time: now.Add(-time.Minute)},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sso := &v1.SSO{}
+			if test.annotation != "" {
+				sso.SetAnnotations(map[string]string{rotateCookieSecretAnnotation: test.annotation})
+			}
+			sso.Spec.CookieSpec.RotationInterval = test.interval
+			sso.Status.CookieSecretRotated = test.rotated
+
+			due, _, err := cookieRotationDue(sso, now, minInterval)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.due, due)
+		})
+	}
+}
+
+func TestCookieRotationDueInvalidAnnotation(t *testing.T) {
+	rotated := metav1.NewTime(time.Now().Add(-time.Hour))
+	sso := &v1.SSO{}
+	sso.SetAnnotations(map[string]string{rotateCookieSecretAnnotation: "now"})
+	sso.Status.CookieSecretRotated = &rotated
+
+	_, _, err := cookieRotationDue(sso, time.Now(), time.Minute)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,19 @@
+package operator
+
+import (
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// event emits an event on the SSO, which lets the users without access to the operator logs follow its progress
+func (h *Handler) event(sso *v1.SSO, eventType string, reason string, messageFmt string, args ...interface{}) {
+	h.recorder.Eventf(kubernetes.SSOReference(sso), eventType, reason, messageFmt, args...)
+}
+
+// failStatus records a failure in the status of the SSO, emits it as a warning event and returns the cause
+func (h *Handler) failStatus(sso *v1.SSO, previous *v1.SSOStatus, condType v1.SSOConditionType, reason string,
+	cause error) error {
+	h.event(sso, corev1.EventTypeWarning, reason, "%v", cause)
+	return failStatus(sso, previous, condType, reason, cause)
+}
@@ -0,0 +1,21 @@
+package operator
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestEvent(t *testing.T) {
+	recorder := record.NewFakeRecorder(1)
+	h := &Handler{recorder: recorder}
+	sso := &v1.SSO{ObjectMeta: metav1.ObjectMeta{Namespace: "jx", Name: "test"}}
+
+	h.event(sso, corev1.EventTypeWarning, "IngressHostNotFound", "no ingress host found for application %q", "test")
+
+	assert.Equal(t, `Warning IngressHostNotFound no ingress host found for application "test"`, <-recorder.Events)
+}
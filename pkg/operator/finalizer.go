@@ -0,0 +1,34 @@
+package operator
+
+import (
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+)
+
+// cleanupFinalizer blocks the deletion of a SSO until its ingress and OIDC client are cleaned up
+const cleanupFinalizer = "jenkins.io/sso-cleanup"
+
+func hasFinalizer(sso *v1.SSO) bool {
+	for _, f := range sso.GetFinalizers() {
+		if f == cleanupFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func addFinalizer(sso *v1.SSO) {
+	if hasFinalizer(sso) {
+		return
+	}
+	sso.SetFinalizers(append(sso.GetFinalizers(), cleanupFinalizer))
+}
+
+func removeFinalizer(sso *v1.SSO) {
+	finalizers := []string{}
+	for _, f := range sso.GetFinalizers() {
+		if f != cleanupFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	sso.SetFinalizers(finalizers)
+}
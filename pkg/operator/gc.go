@@ -0,0 +1,113 @@
+package operator
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/idp"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// clientGracePeriod protects the OIDC clients of SSOs which are still being initialized
+const clientGracePeriod = 10 * time.Minute
+
+// ClientCollector periodically deletes from the identity provider the OIDC clients which are not owned anymore by a SSO
+type ClientCollector struct {
+	clients        idp.Registrar
+	registry       *clientRegistry
+	watchNamespace string
+	interval       time.Duration
+	dryRun         bool
+}
+
+// NewClientCollector returns a new garbage collector for orphaned OIDC clients, in dry-run mode the
+// orphaned clients are only reported
+func NewClientCollector(clients idp.Registrar, namespace string, watchNamespace string,
+	interval time.Duration, dryRun bool) *ClientCollector {
+	return &ClientCollector{
+		clients:        clients,
+		registry:       newClientRegistry(namespace),
+		watchNamespace: watchNamespace,
+		interval:       interval,
+		dryRun:         dryRun,
+	}
+}
+
+// Run collects the orphaned OIDC clients at every interval until the context is done
+func (c *ClientCollector) Run(ctx context.Context) {
+	logrus.Infof("Collecting orphaned OIDC clients every %s (dry-run: %t)", c.interval, c.dryRun)
+	wait.Until(func() {
+		orphans, err := c.Collect(ctx)
+		if err != nil {
+			collectionErrors.Inc()
+			logrus.Errorf("failed to collect orphaned OIDC clients: %v", err)
+			return
+		}
+		orphanedClients.Set(float64(orphans))
+	}, c.interval, ctx.Done())
+}
+
+// Collect deletes the orphaned OIDC clients from the identity provider and returns how many were found
+func (c *ClientCollector) Collect(ctx context.Context) (int, error) {
+	records, err := c.registry.list()
+	if err != nil {
+		return 0, errors.Wrap(err, "listing the OIDC clients created by the operator")
+	}
+	client, err := kubernetes.GetJenkinsClient()
+	if err != nil {
+		return 0, errors.Wrap(err, "getting Jenkins client")
+	}
+	ssoList, err := client.JenkinsV1().SSOs(c.watchNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, errors.Wrap(err, "listing SSO resources")
+	}
+	ssos := map[types.UID]*v1.SSO{}
+	for i := range ssoList.Items {
+		ssos[ssoList.Items[i].GetUID()] = &ssoList.Items[i]
+	}
+
+	orphans := 0
+	for clientID, record := range records {
+		if !isOrphan(clientID, record, ssos, time.Now()) {
+			continue
+		}
+		orphans++
+		if c.dryRun {
+			logrus.Infof("Found orphaned OIDC client '%s' of SSO '%s/%s'", clientID, record.Namespace, record.Name)
+			continue
+		}
+		logrus.Infof("Deleting orphaned OIDC client '%s' of SSO '%s/%s'", clientID, record.Namespace, record.Name)
+		err := c.clients.DeleteClient(ctx, clientID)
+		if err != nil && !idp.IsNotFound(err) {
+			return orphans, errors.Wrapf(err, "deleting orphaned OIDC client '%s' from the identity provider", clientID)
+		}
+		err = c.registry.forget(clientID)
+		if err != nil {
+			return orphans, errors.Wrapf(err, "removing orphaned OIDC client '%s' from registry", clientID)
+		}
+		deletedClients.Inc()
+	}
+	return orphans, nil
+}
+
+// isOrphan checks if an OIDC client is not used anymore by the SSO which created it. The clients of
+// SSOs which are being deleted are left to the finalizer.
+func isOrphan(clientID string, record clientRecord, ssos map[types.UID]*v1.SSO, now time.Time) bool {
+	if now.Sub(record.Created.Time) < clientGracePeriod {
+		return false
+	}
+	sso, ok := ssos[record.UID]
+	if !ok {
+		return true
+	}
+	if sso.GetDeletionTimestamp() != nil {
+		return false
+	}
+	return sso.Status.Initialized && sso.Status.ClientID != clientID && sso.Status.PendingClientID != clientID
+}
@@ -0,0 +1,78 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestIsOrphan(t *testing.T) {
+	now := time.Now()
+	old := metav1.NewTime(now.Add(-2 * clientGracePeriod))
+	deleted := metav1.NewTime(now)
+	ssos := map[types.UID]*v1.SSO{
+		"initialized": {
+			Status: v1.SSOStatus{Initialized: true, ClientID: "current"},
+		},
+		"rotating": {
+			Status: v1.SSOStatus{Initialized: true, ClientID: "current", PendingClientID: "pending"},
+		},
+		"initializing": {
+			Status: v1.SSOStatus{ClientID: "current"},
+		},
+		"deleting": {
+			ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &deleted},
+			Status:     v1.SSOStatus{Initialized: true, ClientID: "current"},
+		},
+	}
+
+	tests := map[string]struct {
+		clientID string
+		record   clientRecord
+		orphan   bool
+	}{
+		"sso deleted": {
+			clientID: "current",
+			record:   clientRecord{UID: "missing", Created: old},
+			orphan:   true,
+		},
+		"sso deleted within grace period": {
+			clientID: "current",
+			record:   clientRecord{UID: "missing", Created: metav1.NewTime(now)},
+			orphan:   false,
+		},
+		"client in use": {
+			clientID: "current",
+			record:   clientRecord{UID: "initialized", Created: old},
+			orphan:   false,
+		},
+		"client replaced": {
+			clientID: "previous",
+			record:   clientRecord{UID: "initialized", Created: old},
+			orphan:   true,
+		},
+		"client rotating": {
+			clientID: "pending",
+			record:   clientRecord{UID: "rotating", Created: old},
+			orphan:   false,
+		},
+		"sso initializing": {
+			clientID: "previous",
+			record:   clientRecord{UID: "initializing", Created: old},
+			orphan:   false,
+		},
+		"sso finalizing": {
+			clientID: "previous",
+			record:   clientRecord{UID: "deleting", Created: old},
+			orphan:   false,
+		},
+	}
+
+	for name, tc := range tests {
+		assert.Equal(t, tc.orphan, isOrphan(tc.clientID, tc.record, ssos, now), name)
+	}
+}
@@ -0,0 +1,114 @@
+package operator
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	orphanedClients = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "sso_operator",
+		Subsystem: "client_gc",
+		Name:      "orphaned_clients",
+		Help:      "Number of orphaned OIDC clients found in dex by the last garbage collection.",
+	})
+	deletedClients = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "sso_operator",
+		Subsystem: "client_gc",
+		Name:      "deleted_clients_total",
+		Help:      "Total number of orphaned OIDC clients deleted from dex.",
+	})
+	collectionErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "sso_operator",
+		Subsystem: "client_gc",
+		Name:      "errors_total",
+		Help:      "Total number of failed garbage collections of OIDC clients.",
+	})
+	reconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "sso_operator",
+		Subsystem: "controller",
+		Name:      "reconcile_total",
+		Help:      "Total number of reconciliations of a SSO.",
+	}, []string{"namespace", "sso"})
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "sso_operator",
+		Subsystem: "controller",
+		Name:      "reconcile_errors_total",
+		Help:      "Total number of failed reconciliations of a SSO.",
+	}, []string{"namespace", "sso"})
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "sso_operator",
+		Subsystem: "controller",
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of the reconciliations of a SSO.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"namespace", "sso"})
+)
+
+func init() {
+	prometheus.MustRegister(orphanedClients, deletedClients, collectionErrors,
+		reconciles, reconcileErrors, reconcileDuration)
+}
+
+// observeReconcile records the duration and the result of a reconciliation of a SSO
+func observeReconcile(namespace string, name string, duration time.Duration, failed bool) {
+	reconciles.WithLabelValues(namespace, name).Inc()
+	if failed {
+		reconcileErrors.WithLabelValues(namespace, name).Inc()
+	}
+	reconcileDuration.WithLabelValues(namespace, name).Observe(duration.Seconds())
+}
+
+// forgetReconcile removes the metrics of a deleted SSO
+func forgetReconcile(namespace string, name string) {
+	reconciles.DeleteLabelValues(namespace, name)
+	reconcileErrors.DeleteLabelValues(namespace, name)
+	reconcileDuration.DeleteLabelValues(namespace, name)
+}
+
+var ssoPhases = []v1.SSOPhase{v1.SSOPhaseInitializing, v1.SSOPhaseReady, v1.SSOPhaseFailed, v1.SSOPhaseDeleting}
+
+// PhaseCollector counts the SSOs by lifecycle phase from the manager cache when the metrics are scraped
+type PhaseCollector struct {
+	ssos client.Reader
+	desc *prometheus.Desc
+}
+
+// NewPhaseCollector creates a collector of the number of SSOs by phase
+func NewPhaseCollector(ssos client.Reader) *PhaseCollector {
+	return &PhaseCollector{
+		ssos: ssos,
+		desc: prometheus.NewDesc("sso_operator_ssos", "Number of SSOs by lifecycle phase.", []string{"phase"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *PhaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector, the SSOs which have no phase yet are counted as initializing
+func (c *PhaseCollector) Collect(ch chan<- prometheus.Metric) {
+	ssos := &v1.SSOList{}
+	err := c.ssos.List(context.Background(), ssos)
+	if err != nil {
+		logrus.Warnf("failed to list the SSOs for the metrics: %v", err)
+		return
+	}
+	counts := map[v1.SSOPhase]int{}
+	for _, sso := range ssos.Items {
+		phase := sso.Status.Phase
+		if phase == "" {
+			phase = v1.SSOPhaseInitializing
+		}
+		counts[phase]++
+	}
+	for _, phase := range ssoPhases {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts[phase]), string(phase))
+	}
+}
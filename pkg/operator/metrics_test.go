@@ -0,0 +1,34 @@
+package operator
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPhaseCollector(t *testing.T) {
+	ssos := []runtime.Object{
+		&v1.SSO{ObjectMeta: metav1.ObjectMeta{Namespace: "jx", Name: "new"}},
+		&v1.SSO{ObjectMeta: metav1.ObjectMeta{Namespace: "jx", Name: "ready"}, Status: v1.SSOStatus{Phase: v1.SSOPhaseReady}},
+		&v1.SSO{ObjectMeta: metav1.ObjectMeta{Namespace: "jx", Name: "other"}, Status: v1.SSOStatus{Phase: v1.SSOPhaseReady}},
+		&v1.SSO{ObjectMeta: metav1.ObjectMeta{Namespace: "jx", Name: "broken"}, Status: v1.SSOStatus{Phase: v1.SSOPhaseFailed}},
+	}
+
+	expected := `
+# HELP sso_operator_ssos Number of SSOs by lifecycle phase.
+# TYPE sso_operator_ssos gauge
+sso_operator_ssos{phase="Deleting"} 0
+sso_operator_ssos{phase="Failed"} 1
+sso_operator_ssos{phase="Initializing"} 1
+sso_operator_ssos{phase="Ready"} 2
+`
+	collector := NewPhaseCollector(fake.NewClientBuilder().WithScheme(kubernetes.Scheme).WithRuntimeObjects(ssos...).Build())
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+}
@@ -3,18 +3,40 @@ package operator
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
-	"github.com/jenkins-x/sso-operator/pkg/dex"
+	"github.com/jenkins-x/sso-operator/pkg/defaults"
+	"github.com/jenkins-x/sso-operator/pkg/idp"
 	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
 	"github.com/jenkins-x/sso-operator/pkg/proxy"
-	"github.com/operator-framework/operator-sdk/pkg/sdk"
+	"github.com/jenkins-x/sso-operator/pkg/validation"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-// NewHandler returns a new SSO operator event handler
-func NewHandler(dexClient *dex.Client, namespace string, clusterRoleName string) (sdk.Handler, error) {
+// readyRequeueInterval is the delay after which a SSO waiting for a resource of its proxy is reconciled again,
+// the changes of the proxy resources trigger a reconciliation before this delay
+const readyRequeueInterval = 15 * time.Second
+
+// clientCheckInterval is the minimum delay between two checks of the OIDC client of a SSO in the identity provider.
+// A check is a call to the identity provider, and a write to the storage of a dex which does not serve the GetClient
+// call, hence the client is only checked by the periodic resync with its default period and not by every
+// reconciliation triggered by a change.
+const clientCheckInterval = 5 * time.Minute
+
+// NewHandler returns a new SSO reconciler which reads the SSOs from the given reader, usually the manager cache
+func NewHandler(clients idp.Registrar, namespace string, clusterRoleName string, cookieMinInterval time.Duration,
+	defaults *defaults.Defaults, ssos client.Reader, recorder record.EventRecorder) (*Handler, error) {
 	config, err := getOperatorConfigFromSecret(namespace)
 	if err != nil {
 		logrus.Info("unable to fetch existing cookie key: " + err.Error())
@@ -34,124 +56,665 @@ func NewHandler(dexClient *dex.Client, namespace string, clusterRoleName string)
 		logrus.Info("operator using existing cookie key")
 	}
 	return &Handler{
-		dexClient:       dexClient,
-		clusterRoleName: clusterRoleName,
-		operatorConfig:  *config,
+		clients:           clients,
+		clusterRoleName:   clusterRoleName,
+		operatorConfig:    *config,
+		registry:          newClientRegistry(namespace),
+		clientChecks:      map[string]clientCheck{},
+		cookieMinInterval: cookieMinInterval,
+		defaults:          defaults,
+		ssos:              ssos,
+		recorder:          recorder,
 	}, nil
 }
 
-// Handler is a SSO operator event handler
+// Handler reconciles the SSOs with their OIDC client in the identity provider and their oauth2_proxy resources
 type Handler struct {
-	dexClient       *dex.Client
-	clusterRoleName string
-	operatorConfig  operatorConfig
+	clients           idp.Registrar
+	clusterRoleName   string
+	operatorConfig    operatorConfig
+	registry          *clientRegistry
+	clientChecksLock  sync.Mutex
+	clientChecks      map[string]clientCheck
+	cookieMinInterval time.Duration
+	defaults          *defaults.Defaults
+	ssos              client.Reader
+	recorder          record.EventRecorder
 }
 
-// Handle handles SSO operator events
-func (h *Handler) Handle(ctx context.Context, event sdk.Event) error {
-	switch o := event.Object.(type) {
-	case *v1.SSO:
-		sso := o.DeepCopy()
+// Reconcile reconciles the SSO of the request, a SSO which waits for a resource of its proxy is requeued
+func (h *Handler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	sso := &v1.SSO{}
+	err := h.ssos.Get(ctx, req.NamespacedName, sso)
+	if apierrors.IsNotFound(err) {
+		// The resources of a deleted SSO were cleaned up by its finalizer or are garbage collected
+		forgetReconcile(req.Namespace, req.Name)
+		h.forgetClientCheck(req.Namespace, req.Name)
+		return reconcile.Result{}, nil
+	}
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "getting '%s' SSO", req)
+	}
 
-		// Ensure that the operator has permissions in the namespace of the single sing-on resource
-		saName, err := kubernetes.EnsureClusterRoleBinding(h.clusterRoleName, sso.GetNamespace())
-		if err != nil {
-			return errors.Wrapf(err, "ensuring cluster role '%s' has a binding to a service account in the namespace '%s'",
-				h.clusterRoleName, sso.GetNamespace())
-		}
-
-		// Cleanup all resources when a SSO CR is deleted
-		if event.Deleted && sso.Status.Initialized {
-			err := proxy.Cleanup(sso, sso.GetName(), saName)
-			if err != nil {
-				return errors.Wrapf(err, "cleaning up '%s' SSO proxy", sso.GetName())
-			}
-			clientID := sso.Status.ClientID
-			err = h.dexClient.DeleteClient(ctx, clientID)
-			if err != nil {
-				return errors.Wrapf(err, "deleting OIDC client '%s' from dex", clientID)
-			}
-			return nil
-		}
-
-		// Check if SSO was already initialized
-		initialized, err := kubernetes.IsSSOInitialized(sso)
+	start := time.Now()
+	err = h.handle(ctx, sso)
+	observeReconcile(req.Namespace, req.Name, time.Since(start), err != nil && !proxy.IsNotReady(err))
+	if proxy.IsNotReady(err) {
+		logrus.Infof("SSO '%s' is waiting: %v", req, err)
+		return reconcile.Result{RequeueAfter: readyRequeueInterval}, nil
+	}
+	return reconcile.Result{}, err
+}
+
+// handle initializes, reconciles or finalizes the SSO
+func (h *Handler) handle(ctx context.Context, sso *v1.SSO) error {
+	// Cleanup all resources before the finalizer allows the SSO CR to be deleted
+	if sso.GetDeletionTimestamp() != nil {
+		return h.finalize(ctx, sso)
+	}
+
+	if !hasFinalizer(sso) {
+		addFinalizer(sso)
+		updated, err := kubernetes.UpdateSSO(sso)
 		if err != nil {
-			logrus.Infof("Failed to get the status of SSO '%s'. Error: %v", sso.GetName(), err)
-			initialized = false
+			return errors.Wrapf(err, "adding the finalizer to '%s' SSO", sso.GetName())
 		}
-		if initialized {
-			return nil
+		sso = updated
+	}
+
+	// Check if SSO was already initialized
+	initialized, err := kubernetes.IsSSOInitialized(sso)
+	if err != nil {
+		logrus.Infof("Failed to get the status of SSO '%s'. Error: %v", sso.GetName(), err)
+		initialized = false
+	}
+	// Fill in memory the fields which were not defaulted by the mutating webhook
+	if h.defaults != nil {
+		h.defaults.Apply(sso)
+	}
+
+	// Reject an invalid spec before any change is made in the identity provider or in the oauth2_proxy
+	previous := sso.Status.DeepCopy()
+	errs := validation.ValidateSSO(sso, kubernetes.GetService)
+	if len(errs) > 0 {
+		return h.failStatus(sso, previous, v1.SSOReady, "InvalidSpec",
+			errors.Wrapf(errs.ToAggregate(), "validating '%s' SSO", sso.GetName()))
+	}
+
+	if initialized {
+		return h.reconcile(ctx, sso)
+	}
+	logrus.Infof("Initializing SSO '%s'", sso.GetName())
+	sso.Status.Phase = v1.SSOPhaseInitializing
+	recordStatus(sso, previous)
+
+	// Crate a new OIDC client in the identity provider, the client ID and secret are stored before the client is
+	// created in order to adopt the client when the initialization is retried
+	id := clientID(sso)
+	err = h.registry.record(id, sso)
+	if err != nil {
+		return h.failStatus(sso, previous, v1.SSODexClientReady, "DexClientRecordFailed",
+			errors.Wrapf(err, "recording the OIDC client '%s'", id))
+	}
+	secret, generated, err := ensureClientSecret(sso, id)
+	if err != nil {
+		return h.failStatus(sso, previous, v1.SSODexClientReady, "DexClientSecretFailed",
+			errors.Wrapf(err, "storing the secret of OIDC client '%s'", id))
+	}
+	if generated {
+		// A client left behind with the same ID has a different secret and cannot be adopted
+		err = h.clients.DeleteClient(ctx, id)
+		if err != nil && !idp.IsNotFound(err) {
+			return h.failStatus(sso, previous, v1.SSODexClientReady, "DexClientCreateFailed",
+				errors.Wrapf(err, "deleting the stale OIDC client '%s' from the identity provider", id))
 		}
-		logrus.Infof("Initializing SSO '%s'", sso.GetName())
+	}
+	redirectURLs := []string{proxy.FakeRedirectURL()}
+	publicClient := false
+	client, err := h.clients.CreateClient(ctx, &idp.Client{
+		ID:           id,
+		Secret:       secret,
+		RedirectURIs: redirectURLs,
+		Public:       publicClient,
+		Name:         sso.Name,
+	})
+	if err != nil {
+		return h.failStatus(sso, previous, v1.SSODexClientReady, "DexClientCreateFailed",
+			errors.Wrapf(err, "creating the OIDC client '%s' in the identity provider", sso.GetName()))
+	}
+	sso.Status.ClientID = client.ID
+	setCondition(&sso.Status, v1.SSODexClientReady, corev1.ConditionTrue, "DexClientCreated",
+		fmt.Sprintf("OIDC client '%s' created in the identity provider", client.ID))
+	recordStatus(sso, previous)
+	h.event(sso, corev1.EventTypeNormal, "DexClientCreated", "OIDC client '%s' created in the identity provider", client.ID)
+
+	// Deploy the OIDC proxy
+	cookieSecret, err := h.cookieSecret(sso)
+	if err != nil {
+		return h.rollback(ctx, sso, previous, client.ID, v1.SSOProxyDeployed, "CookieSecretFailed",
+			errors.Wrapf(err, "getting the cookie secret of '%s' SSO", sso.GetName()))
+	}
+	proxyResources, err := proxy.Deploy(sso, client, cookieSecret)
+	if proxy.IsNotReady(err) {
+		return waitStatus(sso, previous, v1.SSOProxyDeployed, corev1.ConditionFalse, "ProxyNotReady", err)
+	}
+	if err != nil {
+		return h.rollback(ctx, sso, previous, client.ID, v1.SSOProxyDeployed, "ProxyDeployFailed",
+			errors.Wrapf(err, "deploying '%s' SSO proxy", sso.GetName()))
+	}
+	setCondition(&sso.Status, v1.SSOProxyDeployed, corev1.ConditionTrue, "ProxyDeployed",
+		fmt.Sprintf("oauth2_proxy deployed for %s", upstreamServices(sso)))
+	recordStatus(sso, previous)
+	h.event(sso, corev1.EventTypeNormal, "ProxyDeployed", "oauth2_proxy deployed for %s", upstreamServices(sso))
 
-		// Crate a new OIDC client in dex
-		redirectURLs := []string{proxy.FakeRedirectURL()}
-		publicClient := false
-		client, err := h.dexClient.CreateClient(ctx, redirectURLs, []string{}, publicClient, sso.Name, "")
+	// Expose the OIDC proxy service publicly unless sso config is set to skip it
+	var ingressHosts []string
+	switch {
+	case sso.Spec.SkipExposeService:
+		logrus.Infof("skipping exposecontrolller step for '%s'", sso.GetName())
+		setCondition(&sso.Status, v1.SSOExposed, corev1.ConditionTrue, "ExposeSkipped",
+			"exposing the oauth2_proxy service is skipped")
+		h.event(sso, corev1.EventTypeNormal, "ExposeSkipped", "exposing the oauth2_proxy service is skipped")
+	case !proxy.IsExposeController(sso):
+		ingressHosts, err = proxy.ExposeOwned(sso, proxyResources.Service.GetName(), proxyResources.AppName)
 		if err != nil {
-			return errors.Wrapf(err, "creating the OIDC client '%s' in dex", sso.GetName())
+			return h.rollback(ctx, sso, previous, client.ID, v1.SSOExposed, "ExposeFailed",
+				errors.Wrapf(err, "exposing '%s' SSO proxy", sso.GetName()))
+		}
+		setCondition(&sso.Status, v1.SSOExposed, corev1.ConditionTrue, "Exposed",
+			fmt.Sprintf("service '%s' exposed with %s %v", proxyResources.Service.GetName(), sso.Spec.Exposer, ingressHosts))
+		h.event(sso, corev1.EventTypeNormal, "Exposed", "service '%s' exposed with %s %v",
+			proxyResources.Service.GetName(), sso.Spec.Exposer, ingressHosts)
+	default:
+		saName, err := h.exposeServiceAccount(sso)
+		if err == nil {
+			err = proxy.Expose(sso, proxyResources.Service.GetName(), saName)
+		}
+		if proxy.IsNotReady(err) {
+			return waitStatus(sso, previous, v1.SSOExposed, corev1.ConditionFalse, "Exposing", err)
 		}
-
-		// Deploy the OIDC proxy
-		proxyResources, err := proxy.Deploy(sso, client, h.operatorConfig.ssoCookieKey)
 		if err != nil {
-			return h.deleteClient(ctx, client.Id, errors.Wrapf(err, "deploying '%s' SSO proxy", sso.GetName()))
+			return h.rollback(ctx, sso, previous, client.ID, v1.SSOExposed, "ExposeFailed",
+				errors.Wrapf(err, "exposing '%s' SSO proxy", sso.GetName()))
 		}
+		setCondition(&sso.Status, v1.SSOExposed, corev1.ConditionTrue, "Exposed",
+			fmt.Sprintf("service '%s' exposed", proxyResources.Service.GetName()))
+		h.event(sso, corev1.EventTypeNormal, "Exposed", "exposecontroller job exposed service '%s'",
+			proxyResources.Service.GetName())
+	}
+	recordStatus(sso, previous)
 
-		// Expose the OIDC proxy service publicly unless sso config is set to skip it
-		if sso.Spec.SkipExposeService {
-			logrus.Infof("skipping exposecontrolller step for '%s'", sso.GetName())
-		} else {
-			err = proxy.Expose(sso, proxyResources.Service.GetName(), saName)
-			if err != nil {
-				return h.deleteClient(ctx, client.Id, errors.Wrapf(err, "exposing '%s' SSO proxy", sso.GetName()))
-			}
+	// Update in the identity provider the redirect URL of the OIDC client
+	if ingressHosts == nil {
+		ingressHosts, err = kubernetes.FindIngressHosts(proxyResources.AppName, sso.GetNamespace())
+		if err != nil {
+			return h.rollback(ctx, sso, previous, client.ID, v1.SSOExposed, "IngressNotFound",
+				errors.Wrap(err, "searching ingress hosts"))
 		}
+	}
+
+	if len(ingressHosts) == 0 {
+		return h.rollback(ctx, sso, previous, client.ID, v1.SSOExposed, "IngressHostNotFound",
+			fmt.Errorf("no ingress host found for application %q", proxyResources.AppName))
+	}
+	sso.Status.URLs = proxy.ConvertHostsToURLs(ingressHosts)
 
-		// Update in dex the redirect URL of the OIDC client
-		ingressHosts, err := kubernetes.FindIngressHosts(proxyResources.AppName, sso.GetNamespace())
+	redirectURLs = proxy.ConvertHostsToRedirectURLs(ingressHosts, sso)
+	logrus.Infof("SSO redirect URIs: %v", redirectURLs)
+
+	client.RedirectURIs = redirectURLs
+	err = h.clients.UpdateClient(ctx, client)
+	if err != nil {
+		return h.rollback(ctx, sso, previous, client.ID, v1.SSORedirectURIsSynced, "DexClientUpdateFailed",
+			errors.Wrapf(err, "updating the OIDC client '%s' in the identity provider", client.ID))
+	}
+
+	// Update the OIDC proxy
+	err = proxy.Update(proxyResources, sso, client, cookieSecret)
+	if err != nil {
+		return h.rollback(ctx, sso, previous, client.ID, v1.SSORedirectURIsSynced, "ProxyUpdateFailed",
+			errors.Wrapf(err, "updating '%s' SSO proxy", sso.GetName()))
+	}
+	sso.Status.RedirectURIs = redirectURLs
+	setCondition(&sso.Status, v1.SSORedirectURIsSynced, corev1.ConditionTrue, "RedirectURIsSynced",
+		"redirect URIs updated in the identity provider and oauth2_proxy")
+	h.event(sso, corev1.EventTypeNormal, "RedirectURIsSynced", "redirect URIs %v updated in the identity provider and oauth2_proxy",
+		redirectURLs)
+
+	// Update the status of SSO CR, the SSO becomes ready once the updated proxy is rolled out
+	sso.Status.ClientID = client.ID
+	sso.Status.Initialized = true
+	sso.Status.ObservedGeneration = sso.GetGeneration()
+	readyErr := checkReady(sso)
+	if readyErr != nil && !proxy.IsNotReady(readyErr) {
+		setFailed(&sso.Status, v1.SSOReady, "ProxyRolloutFailed", readyErr)
+		h.event(sso, corev1.EventTypeWarning, "ProxyRolloutFailed", "%v", readyErr)
+	}
+	err = updateStatus(sso, previous)
+	if err != nil {
+		return h.deleteClient(ctx, sso, client.ID, errors.Wrapf(err, "updating '%s' SSO CRD", sso.GetName()))
+	}
+
+	logrus.Infof("SSO proxy '%s' initialized", sso.GetName())
+	h.event(sso, corev1.EventTypeNormal, "Initialized", "SSO proxy initialized")
+	return readyErr
+}
+
+// reconcile applies the spec changes of an already initialized SSO to its oauth2_proxy resources
+func (h *Handler) reconcile(ctx context.Context, sso *v1.SSO) error {
+	previous := sso.Status.DeepCopy()
+	cookieSecret, err := h.cookieSecret(sso)
+	if err != nil {
+		return h.failStatus(sso, previous, v1.SSOProxyDeployed, "CookieSecretFailed",
+			errors.Wrapf(err, "getting the cookie secret of '%s' SSO", sso.GetName()))
+	}
+	err = h.ensureClient(ctx, sso, cookieSecret)
+	if err != nil {
+		return h.failStatus(sso, previous, v1.SSODexClientReady, "DexClientCreateFailed",
+			errors.Wrapf(err, "re-creating the OIDC client of '%s' SSO", sso.GetName()))
+	}
+	rotate, err := clientRotationDue(sso)
+	if err != nil {
+		return h.failStatus(sso, previous, v1.SSODexClientReady, "ClientSecretRotationFailed",
+			errors.Wrapf(err, "checking the OIDC client rotation of '%s' SSO", sso.GetName()))
+	}
+	if rotate {
+		err = h.rotateClient(ctx, sso, previous, cookieSecret)
+		if proxy.IsNotReady(err) {
+			return waitStatus(sso, previous, v1.SSODexClientReady, corev1.ConditionTrue, "ClientSecretRotating", err)
+		}
 		if err != nil {
-			return h.deleteClient(ctx, client.Id, errors.Wrap(err, "searching ingress hosts"))
+			return h.failStatus(sso, previous, v1.SSODexClientReady, "ClientSecretRotationFailed",
+				errors.Wrapf(err, "rotating the OIDC client secret of '%s' SSO", sso.GetName()))
 		}
+	}
 
-		if len(ingressHosts) == 0 {
-			return h.deleteClient(ctx, client.Id, fmt.Errorf("no ingress host found for application %q", proxyResources.AppName))
+	hosts, changed, err := proxy.Reconcile(sso, cookieSecret)
+	if err != nil {
+		return h.failStatus(sso, previous, v1.SSOProxyDeployed, "ProxyReconcileFailed",
+			errors.Wrapf(err, "reconciling '%s' SSO proxy", sso.GetName()))
+	}
+	if changed {
+		logrus.Infof("SSO proxy '%s' updated to generation %d", sso.GetName(), sso.GetGeneration())
+		h.event(sso, corev1.EventTypeNormal, "ProxyUpdated", "oauth2_proxy updated to generation %d",
+			sso.GetGeneration())
+	}
+	if len(hosts) > 0 {
+		err = h.syncRedirectURIs(ctx, sso, hosts, cookieSecret)
+		if err != nil {
+			return h.failStatus(sso, previous, v1.SSORedirectURIsSynced, "DexClientUpdateFailed",
+				errors.Wrapf(err, "updating the redirect URIs of '%s' SSO", sso.GetName()))
 		}
+	}
+
+	sso.Status.ObservedGeneration = sso.GetGeneration()
+	setCondition(&sso.Status, v1.SSOProxyDeployed, corev1.ConditionTrue, "ProxyDeployed",
+		fmt.Sprintf("oauth2_proxy deployed for %s", upstreamServices(sso)))
+	readyErr := checkReady(sso)
+	if readyErr != nil && !proxy.IsNotReady(readyErr) {
+		return h.failStatus(sso, previous, v1.SSOReady, "ProxyRolloutFailed", readyErr)
+	}
+	err = updateStatus(sso, previous)
+	if err != nil {
+		return err
+	}
+	return readyErr
+}
+
+// syncRedirectURIs updates the redirect URIs of the OIDC client in the identity provider and in the oauth2_proxy
+// when the public hosts of the proxy changed, for instance after the domain or the URL template of the SSO changed
+func (h *Handler) syncRedirectURIs(ctx context.Context, sso *v1.SSO, hosts []string, cookieSecret string) error {
+	redirectURLs := proxy.ConvertHostsToRedirectURLs(hosts, sso)
+	if equality.Semantic.DeepEqual(redirectURLs, sso.Status.RedirectURIs) {
+		return nil
+	}
+	logrus.Infof("SSO redirect URIs: %v", redirectURLs)
+	client, err := proxy.CurrentClient(sso)
+	if err != nil {
+		return errors.Wrap(err, "reading the current OIDC client")
+	}
+	// The proxy config only knows the client ID used by the relying party
+	if sso.Status.ClientID != "" && client.ID != sso.Status.ClientID {
+		client.IssuedID = client.ID
+		client.ID = sso.Status.ClientID
+	}
+	client.RedirectURIs = redirectURLs
+	client.Name = sso.Name
+	err = h.clients.UpdateClient(ctx, client)
+	if err != nil {
+		return errors.Wrapf(err, "updating the OIDC client '%s' in the identity provider", client.ID)
+	}
+	err = proxy.UpdateClient(sso, client, cookieSecret)
+	if err != nil {
+		return errors.Wrapf(err, "configuring oauth2_proxy with the redirect URIs of OIDC client '%s'", client.ID)
+	}
+	sso.Status.URLs = proxy.ConvertHostsToURLs(hosts)
+	sso.Status.RedirectURIs = redirectURLs
+	setCondition(&sso.Status, v1.SSORedirectURIsSynced, corev1.ConditionTrue, "RedirectURIsSynced",
+		"redirect URIs updated in the identity provider and oauth2_proxy")
+	h.event(sso, corev1.EventTypeNormal, "RedirectURIsSynced", "redirect URIs %v updated in the identity provider and oauth2_proxy",
+		redirectURLs)
+	return nil
+}
+
+// checkReady marks the SSO as ready once its oauth2_proxy is rolled out, a NotReadyError is returned until then
+func checkReady(sso *v1.SSO) error {
+	err := proxy.CheckRollout(sso)
+	if proxy.IsNotReady(err) {
+		setCondition(&sso.Status, v1.SSOReady, corev1.ConditionFalse, "ProxyRollingOut", err.Error())
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	setReady(&sso.Status)
+	return nil
+}
+
+// cookieSecret returns the key which signs the cookies of the SSO proxy, the key is rotated when a rotation
+// is due and the time of the last rotation is recorded in the status. The SSO initialized before the keys
+// were per SSO keep the shared key of the operator until the next rotation.
+func (h *Handler) cookieSecret(sso *v1.SSO) (string, error) {
+	seed := ""
+	if sso.Status.Initialized {
+		seed = h.operatorConfig.ssoCookieKey
+	}
+	cookieSecret, stored, err := ensureCookieSecret(sso, seed)
+	if err != nil {
+		return "", err
+	}
+	now := metav1.Now()
+	if stored || sso.Status.CookieSecretRotated == nil {
+		sso.Status.CookieSecretRotated = &now
+		return cookieSecret, nil
+	}
+
+	due, reason, err := cookieRotationDue(sso, now.Time, h.cookieMinInterval)
+	if err != nil {
+		return "", err
+	}
+	if !due {
+		return cookieSecret, nil
+	}
+	logrus.Infof("Rotating the cookie secret of SSO '%s', %s", sso.GetName(), reason)
+	cookieSecret, _, err = rotateCookieSecret(sso)
+	if err != nil {
+		return "", err
+	}
+	sso.Status.CookieSecretRotated = &now
+	return cookieSecret, nil
+}
+
+// clientCheck records when the OIDC client of a SSO was last found in the identity provider
+type clientCheck struct {
+	id      string
+	checked time.Time
+}
+
+// clientCheckDue tells if the OIDC client of the SSO was not found in the identity provider since the check interval
+func (h *Handler) clientCheckDue(sso *v1.SSO, now time.Time) bool {
+	h.clientChecksLock.Lock()
+	defer h.clientChecksLock.Unlock()
+	check, ok := h.clientChecks[sso.Namespace+"/"+sso.Name]
+	return !ok || check.id != sso.Status.ClientID || now.Sub(check.checked) >= clientCheckInterval
+}
+
+// recordClientCheck records that the OIDC client of the SSO was found in the identity provider
+func (h *Handler) recordClientCheck(sso *v1.SSO, now time.Time) {
+	h.clientChecksLock.Lock()
+	defer h.clientChecksLock.Unlock()
+	h.clientChecks[sso.Namespace+"/"+sso.Name] = clientCheck{id: sso.Status.ClientID, checked: now}
+}
+
+// forgetClientCheck drops the last check of the OIDC client of a deleted SSO
+func (h *Handler) forgetClientCheck(namespace string, name string) {
+	h.clientChecksLock.Lock()
+	defer h.clientChecksLock.Unlock()
+	delete(h.clientChecks, namespace+"/"+name)
+}
+
+// ensureClient checks that the OIDC client of the SSO is still registered in the identity provider, and
+// registers it again with the same ID and secret when it is missing, for instance after the storage of dex
+// was wiped. The identity provider is not required by the reconciliation, a failed check is only logged.
+// A client found in the identity provider is not checked again before the check interval.
+func (h *Handler) ensureClient(ctx context.Context, sso *v1.SSO, cookieSecret string) error {
+	id := sso.Status.ClientID
+	if id == "" {
+		return nil
+	}
+	now := time.Now()
+	if !h.clientCheckDue(sso, now) {
+		return nil
+	}
+	_, err := h.clients.GetClient(ctx, id)
+	if err == nil {
+		h.recordClientCheck(sso, now)
+		return nil
+	}
+	if !idp.IsNotFound(err) {
+		logrus.Warnf("failed to check the OIDC client '%s' of SSO '%s': %v", id, sso.GetName(), err)
+		return nil
+	}
 
-		redirectURLs = proxy.ConvertHostsToRedirectURLs(ingressHosts, sso)
-		logrus.Infof("SSO redirect URIs: %v", redirectURLs)
+	logrus.Infof("OIDC client '%s' of SSO '%s' not found in the identity provider, re-creating it", id, sso.GetName())
+	current, err := proxy.CurrentClient(sso)
+	if err != nil {
+		return errors.Wrap(err, "reading the current OIDC client")
+	}
+	redirectURIs := sso.Status.RedirectURIs
+	if len(redirectURIs) == 0 {
+		redirectURIs = current.RedirectURIs
+	}
+	client, err := h.clients.CreateClient(ctx, &idp.Client{
+		ID:           id,
+		Secret:       current.Secret,
+		RedirectURIs: redirectURIs,
+		Name:         sso.Name,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating the OIDC client '%s' in the identity provider", id)
+	}
 
-		err = h.dexClient.UpdateClient(ctx, client.Id, redirectURLs, []string{}, publicClient, sso.Name, "")
+	// An identity provider which issues the credentials gives the client a new ID and secret
+	if client.OIDCClientID() != current.OIDCClientID() || client.Secret != current.Secret {
+		err = proxy.UpdateClient(sso, client, cookieSecret)
 		if err != nil {
-			return h.deleteClient(ctx, client.Id, errors.Wrapf(err, "updating the OIDC client '%s' in dex", client.Id))
+			return errors.Wrapf(err, "configuring oauth2_proxy with the OIDC client '%s'", id)
 		}
-		client.RedirectUris = redirectURLs
+	}
+	setCondition(&sso.Status, v1.SSODexClientReady, corev1.ConditionTrue, "DexClientRecreated",
+		fmt.Sprintf("OIDC client '%s' re-created in the identity provider", id))
+	h.event(sso, corev1.EventTypeWarning, "DexClientRecreated", "OIDC client '%s' was missing and has been re-created in the identity provider", id)
+	return nil
+}
 
-		// Update the OIDC proxy
-		err = proxy.Update(proxyResources, sso, client, h.operatorConfig.ssoCookieKey)
+// rotateClient replaces the OIDC client of the SSO with a new client which has a new secret, since dex does not
+// allow to change the secret of a client. The old client is only deleted from the identity provider after the
+// oauth2_proxy was rolled out with the new client, a NotReadyError is returned until then and the rotation is
+// resumed by the next reconciliation like a rotation which failed.
+func (h *Handler) rotateClient(ctx context.Context, sso *v1.SSO, previous *v1.SSOStatus, cookieSecret string) error {
+	current, err := proxy.CurrentClient(sso)
+	if err != nil {
+		return errors.Wrap(err, "reading the current OIDC client")
+	}
+	redirectURIs := sso.Status.RedirectURIs
+	if len(redirectURIs) == 0 {
+		redirectURIs = current.RedirectURIs
+	}
+
+	now := metav1.Now()
+	id, secret, err := ensurePendingClient(sso, now.Time)
+	if err != nil {
+		return errors.Wrap(err, "storing the new OIDC client secret")
+	}
+	if sso.Status.PendingClientID != id {
+		logrus.Infof("Rotating the OIDC client secret of SSO '%s'", sso.GetName())
+		sso.Status.PendingClientID = id
+		err = updateStatus(sso, previous)
 		if err != nil {
-			return h.deleteClient(ctx, client.Id, errors.Wrapf(err, "updating '%s' SSO proxy", sso.GetName()))
+			return err
 		}
+	}
+	err = h.registry.record(id, sso)
+	if err != nil {
+		return errors.Wrapf(err, "recording the OIDC client '%s'", id)
+	}
+	publicClient := false
+	client, err := h.clients.CreateClient(ctx, &idp.Client{
+		ID:           id,
+		Secret:       secret,
+		RedirectURIs: redirectURIs,
+		Public:       publicClient,
+		Name:         sso.Name,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating the OIDC client '%s' in the identity provider", id)
+	}
 
-		// Update the status of SSO CR
-		sso.Status.ClientID = client.Id
-		sso.Status.Initialized = true
-		err = sdk.Update(sso)
+	err = proxy.UpdateClient(sso, client, cookieSecret)
+	if err != nil {
+		return errors.Wrapf(err, "configuring oauth2_proxy with the OIDC client '%s'", id)
+	}
+	err = proxy.CheckRollout(sso)
+	if err != nil {
+		return err
+	}
+
+	// The old secret is only invalidated once the proxy uses the new client
+	oldID := sso.Status.ClientID
+	if oldID != "" && oldID != id {
+		err = h.clients.DeleteClient(ctx, oldID)
+		if err != nil && !idp.IsNotFound(err) {
+			return errors.Wrapf(err, "deleting the OIDC client '%s' from the identity provider", oldID)
+		}
+		err = h.registry.forget(oldID)
 		if err != nil {
-			return h.deleteClient(ctx, client.Id, errors.Wrapf(err, "updating '%s' SSO CRD", sso.GetName()))
+			logrus.Warnf("failed to remove the OIDC client '%s' from registry: %v", oldID, err)
 		}
+	}
+	err = promotePendingClient(sso)
+	if err != nil {
+		return err
+	}
+	sso.Status.ClientID = id
+	sso.Status.PendingClientID = ""
+	sso.Status.ClientSecretRotated = &now
+	setCondition(&sso.Status, v1.SSODexClientReady, corev1.ConditionTrue, "ClientSecretRotated",
+		fmt.Sprintf("OIDC client '%s' created in the identity provider with a new secret", id))
+	logrus.Infof("OIDC client secret of SSO '%s' rotated", sso.GetName())
+	h.event(sso, corev1.EventTypeNormal, "ClientSecretRotated", "OIDC client '%s' created in the identity provider with a new secret", id)
+	return nil
+}
+
+// finalize cleans up the SSO resources which are not garbage collected and removes the finalizer
+func (h *Handler) finalize(ctx context.Context, sso *v1.SSO) error {
+	if !hasFinalizer(sso) {
+		return nil
+	}
+	logrus.Infof("Cleaning up SSO '%s'", sso.GetName())
+	previous := sso.Status.DeepCopy()
+	sso.Status.Phase = v1.SSOPhaseDeleting
+	recordStatus(sso, previous)
 
-		logrus.Infof("SSO proxy '%s' initialized", sso.GetName())
+	err := h.cleanup(ctx, sso)
+	if proxy.IsNotReady(err) {
+		return waitStatus(sso, previous, v1.SSOExposed, corev1.ConditionFalse, "CleaningUp", err)
 	}
+	if err != nil {
+		return h.failStatus(sso, previous, v1.SSOReady, "CleanupFailed", err)
+	}
+
+	h.event(sso, corev1.EventTypeNormal, "CleanedUp", "oauth2_proxy exposure and OIDC client cleaned up")
+	removeFinalizer(sso)
+	_, err = kubernetes.UpdateSSO(sso)
+	if err != nil {
+		return errors.Wrapf(err, "removing the finalizer from '%s' SSO", sso.GetName())
+	}
+	logrus.Infof("SSO '%s' cleaned up", sso.GetName())
 	return nil
 }
 
-// deleteClient ensure that the OIDC client is removed from dex
-func (h *Handler) deleteClient(ctx context.Context, id string, cause error) error {
-	err := h.dexClient.DeleteClient(ctx, id)
+// cleanup removes the ingress of the SSO proxy and the OIDC client from the identity provider, a client
+// which is not found is considered already deleted
+func (h *Handler) cleanup(ctx context.Context, sso *v1.SSO) error {
+	// The resources which expose the proxy are deleted before the OIDC client to stop routing requests to the proxy
+	if !sso.Spec.SkipExposeService {
+		err := h.unexpose(sso)
+		if err != nil {
+			return errors.Wrapf(err, "cleaning up '%s' SSO proxy", sso.GetName())
+		}
+	}
+	for _, clientID := range []string{sso.Status.ClientID, sso.Status.PendingClientID} {
+		if clientID == "" {
+			continue
+		}
+		err := h.clients.DeleteClient(ctx, clientID)
+		if err != nil && !idp.IsNotFound(err) {
+			return errors.Wrapf(err, "deleting OIDC client '%s' from the identity provider", clientID)
+		}
+		err = h.registry.forget(clientID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unexpose deletes the resources which publicly expose the proxy of the SSO
+func (h *Handler) unexpose(sso *v1.SSO) error {
+	if !proxy.IsExposeController(sso) {
+		return proxy.DeleteOwned(sso)
+	}
+	saName, err := h.exposeServiceAccount(sso)
+	if err != nil {
+		return err
+	}
+	return proxy.Cleanup(sso, sso.GetName(), saName)
+}
+
+// exposeServiceAccount ensures that the exposecontroller jobs have a service account with the permissions of the
+// operator in the namespace of the SSO
+func (h *Handler) exposeServiceAccount(sso *v1.SSO) (string, error) {
+	saName, err := kubernetes.EnsureClusterRoleBinding(h.clusterRoleName, sso.GetNamespace())
+	if err != nil {
+		return "", errors.Wrapf(err, "ensuring cluster role '%s' has a binding to a service account in the namespace '%s'",
+			h.clusterRoleName, sso.GetNamespace())
+	}
+	return saName, nil
+}
+
+// rollback removes the OIDC client from the identity provider and records the failed step in the status of the SSO
+func (h *Handler) rollback(ctx context.Context, sso *v1.SSO, previous *v1.SSOStatus, clientID string,
+	condType v1.SSOConditionType, reason string, cause error) error {
+	err := h.deleteClient(ctx, sso, clientID, cause)
+	if err == cause {
+		sso.Status.ClientID = ""
+		setCondition(&sso.Status, v1.SSODexClientReady, corev1.ConditionFalse, "RolledBack",
+			fmt.Sprintf("OIDC client '%s' deleted from the identity provider", clientID))
+	}
+	return h.failStatus(sso, previous, condType, reason, err)
+}
+
+// deleteClient ensure that the OIDC client is removed from the identity provider
+func (h *Handler) deleteClient(ctx context.Context, sso *v1.SSO, id string, cause error) error {
+	err := h.clients.DeleteClient(ctx, id)
 	if err != nil {
+		h.event(sso, corev1.EventTypeWarning, "RollbackFailed", "failed to delete the OIDC client '%s' from the identity provider: %v", id, err)
 		return errors.Wrapf(err, "%s. Deleteing the OIDC client", cause.Error())
 	}
+	h.event(sso, corev1.EventTypeNormal, "RolledBack", "OIDC client '%s' deleted from the identity provider", id)
+	err = h.registry.forget(id)
+	if err != nil {
+		logrus.Warnf("failed to remove the OIDC client '%s' from registry: %v", id, err)
+	}
 	return cause
 }
+
+// upstreamServices returns a readable list of the services protected by the SSO proxy
+func upstreamServices(sso *v1.SSO) string {
+	services := []string{}
+	for _, upstream := range proxy.Upstreams(sso) {
+		services = append(services, fmt.Sprintf("'%s'", upstream.Service))
+	}
+	return "service " + strings.Join(services, ", ")
+}
@@ -0,0 +1,642 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dexidp/dex/api/v2"
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	jenkinsfake "github.com/jenkins-x/sso-operator/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/sso-operator/pkg/dex"
+	"github.com/jenkins-x/sso-operator/pkg/dex/dextest"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes/kubetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testNamespace         = "jx-staging"
+	testOperatorNamespace = "sso-operator"
+)
+
+var apiServer *kubetest.Server
+
+func TestMain(m *testing.M) {
+	apiServer = kubetest.NewServer()
+	err := apiServer.Install()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "installing the fake API server: %v\n", err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	apiServer.Close()
+	os.Exit(code)
+}
+
+// testEnv is a handler wired to the fake API server and to a fake dex
+type testEnv struct {
+	handler  *Handler
+	kube     *fake.Clientset
+	jenkins  *jenkinsfake.Clientset
+	dex      *dextest.Server
+	recorder *record.FakeRecorder
+	cache    client.Client
+	sso      *v1.SSO
+}
+
+func testSSO() *v1.SSO {
+	return &v1.SSO{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sso-golang-http",
+			Namespace: testNamespace,
+			UID:       "0b7c4a4e-6f3c-4d0e-9a53-2d1f7f1c9e11",
+		},
+		Spec: v1.SSOSpec{
+			OIDCIssuerURL:   "https://dex.jx-staging.example.com",
+			UpstreamService: "golang-http",
+			Domain:          "example.com",
+			Exposer:         v1.ExposerIngress,
+			ProxyImage:      "quay.io/pusher/oauth2_proxy",
+			ProxyImageTag:   "v3.2.0",
+			CookieSpec: v1.CookieSpec{
+				Name:    "sso-golang-http",
+				Expire:  "168h",
+				Refresh: "60m",
+			},
+		},
+	}
+}
+
+// newTestEnv stores the SSO, its upstream service and a running proxy pod, and rolls out the proxy
+// deployments as soon as they are written
+func newTestEnv(t *testing.T, sso *v1.SSO) *testEnv {
+	upstream := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: sso.Spec.UpstreamService, Namespace: testNamespace},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "proxy", Namespace: testNamespace, Labels: map[string]string{"sso": sso.GetName()}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	kube := fake.NewSimpleClientset(upstream, pod)
+	kube.PrependReactor("*", "deployments", rollOut)
+	jenkins := jenkinsfake.NewSimpleClientset(sso)
+	apiServer.Reset(kube, jenkins)
+
+	dexServer, err := dextest.NewServer()
+	require.NoError(t, err)
+	clients, err := dex.NewClient(&dex.Options{
+		HostAndPort: dexServer.Addr,
+		ClientCrt:   dexServer.CertFile,
+		ClientKey:   dexServer.KeyFile,
+		ClientCA:    dexServer.CAFile,
+	})
+	if err != nil {
+		dexServer.Close()
+	}
+	require.NoError(t, err)
+
+	ssoCache := crfake.NewClientBuilder().WithScheme(kubernetes.Scheme).WithObjects(sso.DeepCopy()).Build()
+	recorder := record.NewFakeRecorder(100)
+	handler, err := NewHandler(clients, testOperatorNamespace, "sso-operator", time.Hour, nil,
+		ssoCache, recorder)
+	if err != nil {
+		dexServer.Close()
+	}
+	require.NoError(t, err)
+	return &testEnv{
+		handler:  handler,
+		kube:     kube,
+		jenkins:  jenkins,
+		dex:      dexServer,
+		recorder: recorder,
+		cache:    ssoCache,
+		sso:      sso,
+	}
+}
+
+// rollOut marks the written deployments as rolled out like the deployment controller would
+func rollOut(action k8stesting.Action) (bool, runtime.Object, error) {
+	var obj runtime.Object
+	switch action := action.(type) {
+	case k8stesting.CreateAction:
+		obj = action.GetObject()
+	case k8stesting.UpdateAction:
+		obj = action.GetObject()
+	}
+	if d, ok := obj.(*appsv1.Deployment); ok {
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		d.Status = appsv1.DeploymentStatus{
+			ObservedGeneration: d.Generation,
+			Replicas:           replicas,
+			UpdatedReplicas:    replicas,
+			AvailableReplicas:  replicas,
+		}
+	}
+	return false, nil, nil
+}
+
+// fail returns a reactor which fails the actions on the objects with the given name, or on all objects
+func fail(name string) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if name == "" {
+			return true, nil, errors.New("injected failure")
+		}
+		if a, ok := action.(k8stesting.CreateAction); ok {
+			if obj, ok := a.GetObject().(metav1.Object); ok && obj.GetName() == name {
+				return true, nil, errors.New("injected failure")
+			}
+		}
+		return false, nil, nil
+	}
+}
+
+func (e *testEnv) close() {
+	e.dex.Close()
+}
+
+func (e *testEnv) reconcile() (reconcile.Result, error) {
+	return e.handler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: e.sso.Namespace, Name: e.sso.Name}})
+}
+
+// resync updates the SSO seen by the handler with the stored SSO, like the manager cache does
+func (e *testEnv) resync(t *testing.T) {
+	sso := e.storedSSO(t)
+	cached := &v1.SSO{}
+	require.NoError(t, e.cache.Get(context.Background(), client.ObjectKeyFromObject(sso), cached))
+	sso.ResourceVersion = cached.ResourceVersion
+	require.NoError(t, e.cache.Update(context.Background(), sso))
+}
+
+func (e *testEnv) storedSSO(t *testing.T) *v1.SSO {
+	sso, err := e.jenkins.JenkinsV1().SSOs(testNamespace).Get(context.Background(), e.sso.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	return sso
+}
+
+func (e *testEnv) events() []string {
+	events := []string{}
+	for {
+		select {
+		case event := <-e.recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func condition(sso *v1.SSO, condType v1.SSOConditionType) v1.SSOCondition {
+	for _, c := range sso.Status.Conditions {
+		if c.Type == condType {
+			return c
+		}
+	}
+	return v1.SSOCondition{}
+}
+
+func hasEvent(events []string, reason string) bool {
+	for _, event := range events {
+		if strings.Contains(event, " "+reason+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReconcileInitializesSSO(t *testing.T) {
+	env := newTestEnv(t, testSSO())
+	defer env.close()
+
+	result, err := env.reconcile()
+
+	require.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, result)
+	sso := env.storedSSO(t)
+	assert.True(t, sso.Status.Initialized)
+	assert.Equal(t, v1.SSOPhaseReady, sso.Status.Phase)
+	assert.Equal(t, clientID(env.sso), sso.Status.ClientID)
+	assert.True(t, hasFinalizer(sso))
+	redirectURI := "https://sso-golang-http.jx-staging.example.com/oauth2/callback"
+	assert.Equal(t, []string{redirectURI}, sso.Status.RedirectURIs)
+
+	client := env.dex.Client(sso.Status.ClientID)
+	require.NotNil(t, client)
+	assert.Equal(t, []string{redirectURI}, client.GetRedirectUris())
+	_, err = env.kube.AppsV1().Deployments(testNamespace).Get(context.Background(), sso.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, env.proxyConfig(t), "upstreams = [\n    \"http://golang-http:80\"\n]")
+
+	events := env.events()
+	for _, reason := range []string{"DexClientCreated", "ProxyDeployed", "Exposed", "RedirectURIsSynced", "Initialized"} {
+		assert.True(t, hasEvent(events, reason), reason)
+	}
+}
+
+func TestReconcileReplacesStaleClient(t *testing.T) {
+	env := newTestEnv(t, testSSO())
+	defer env.close()
+	env.dex.AddClient(&api.Client{Id: clientID(env.sso), Secret: "stale"})
+
+	_, err := env.reconcile()
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, env.dex.Calls(dextest.DeleteClient))
+	assert.Equal(t, 1, env.dex.Len())
+	assert.NotEqual(t, "stale", env.dex.Client(clientID(env.sso)).GetSecret())
+}
+
+func TestReconcileRecreatesMissingClient(t *testing.T) {
+	env := newTestEnv(t, testSSO())
+	defer env.close()
+	_, err := env.reconcile()
+	require.NoError(t, err)
+	id := env.storedSSO(t).Status.ClientID
+	initial := env.dex.Client(id)
+	require.NotNil(t, initial)
+	require.NoError(t, env.handler.clients.DeleteClient(context.Background(), id))
+	env.events()
+	env.resync(t)
+
+	_, err = env.reconcile()
+
+	require.NoError(t, err)
+	client := env.dex.Client(id)
+	require.NotNil(t, client, "the missing OIDC client is re-created")
+	assert.Equal(t, initial.GetSecret(), client.GetSecret())
+	assert.Equal(t, initial.GetRedirectUris(), client.GetRedirectUris())
+	assert.Equal(t, "DexClientRecreated", condition(env.storedSSO(t), v1.SSODexClientReady).Reason)
+	assert.True(t, hasEvent(env.events(), "DexClientRecreated"))
+
+	env.resync(t)
+	_, err = env.reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, 2, env.dex.Calls(dextest.CreateClient), "an existing client is not created again")
+	lookups := env.dex.Calls(dextest.UpdateClient)
+
+	env.resync(t)
+	_, err = env.reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, lookups, env.dex.Calls(dextest.UpdateClient), "a found client is not checked again before the interval")
+}
+
+func TestReconcileRecreatesMissingClientWithNewCredentials(t *testing.T) {
+	env := newTestEnv(t, testSSO())
+	defer env.close()
+	_, err := env.reconcile()
+	require.NoError(t, err)
+	id := env.storedSSO(t).Status.ClientID
+	require.NoError(t, env.handler.clients.DeleteClient(context.Background(), id))
+	// The identity provider issues a new secret when the client is registered again
+	env.dex.Intercept(dextest.CreateClient, func(req interface{}) (interface{}, error) {
+		requested := req.(*api.CreateClientReq).GetClient()
+		client := &api.Client{
+			Id:           requested.GetId(),
+			Secret:       "issued-secret",
+			RedirectUris: requested.GetRedirectUris(),
+			Name:         requested.GetName(),
+		}
+		env.dex.AddClient(client)
+		return &api.CreateClientResp{Client: client}, nil
+	})
+	env.events()
+	env.resync(t)
+
+	_, err = env.reconcile()
+
+	require.NoError(t, err)
+	assert.Equal(t, "issued-secret", env.dex.Client(id).GetSecret())
+	config := env.proxyConfig(t)
+	assert.Contains(t, config, fmt.Sprintf("client_id = %q", id))
+	assert.Contains(t, config, `client_secret = "issued-secret"`, "the proxy uses the issued secret")
+	assert.Equal(t, "DexClientRecreated", condition(env.storedSSO(t), v1.SSODexClientReady).Reason)
+	assert.True(t, hasEvent(env.events(), "DexClientRecreated"))
+}
+
+// annotate sets an annotation on the stored SSO and resyncs the handler with it
+func (e *testEnv) annotate(t *testing.T, key string, value string) {
+	sso := e.storedSSO(t)
+	annotations := sso.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = value
+	sso.SetAnnotations(annotations)
+	_, err := e.jenkins.JenkinsV1().SSOs(testNamespace).Update(context.Background(), sso, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	e.resync(t)
+}
+
+// proxyConfig returns the oauth2_proxy config stored in the proxy secret of the SSO
+func (e *testEnv) proxyConfig(t *testing.T) string {
+	secret, err := e.kube.CoreV1().Secrets(testNamespace).Get(context.Background(), e.sso.Name+"-proxy-secret", metav1.GetOptions{})
+	require.NoError(t, err)
+	return string(secret.Data["oauth2_proxy.cfg"])
+}
+
+func TestReconcileRotatesClientSecret(t *testing.T) {
+	env := newTestEnv(t, testSSO())
+	defer env.close()
+	_, err := env.reconcile()
+	require.NoError(t, err)
+	oldID := env.storedSSO(t).Status.ClientID
+	redirectURIs := env.dex.Client(oldID).GetRedirectUris()
+	env.events()
+	env.annotate(t, rotateClientSecretAnnotation, time.Now().Add(time.Minute).UTC().Format(time.RFC3339))
+
+	for i := 0; i < 3; i++ {
+		_, err = env.reconcile()
+		require.NoError(t, err)
+		env.resync(t)
+	}
+
+	sso := env.storedSSO(t)
+	assert.NotEqual(t, oldID, sso.Status.ClientID)
+	assert.Empty(t, sso.Status.PendingClientID)
+	assert.NotNil(t, sso.Status.ClientSecretRotated)
+	assert.Equal(t, "ClientSecretRotated", condition(sso, v1.SSODexClientReady).Reason)
+	assert.Equal(t, 1, env.dex.Len(), "the old OIDC client is deleted from dex")
+	client := env.dex.Client(sso.Status.ClientID)
+	require.NotNil(t, client)
+	assert.Equal(t, redirectURIs, client.GetRedirectUris())
+	config := env.proxyConfig(t)
+	assert.Contains(t, config, fmt.Sprintf("client_id = %q", client.GetId()))
+	assert.Contains(t, config, fmt.Sprintf("client_secret = %q", client.GetSecret()))
+	assert.True(t, hasEvent(env.events(), "ClientSecretRotated"))
+}
+
+func TestReconcileUpdatesRedirectURIsOnDomainChange(t *testing.T) {
+	env := newTestEnv(t, testSSO())
+	defer env.close()
+	_, err := env.reconcile()
+	require.NoError(t, err)
+	sso := env.storedSSO(t)
+	sso.Spec.Domain = "example.org"
+	_, err = env.jenkins.JenkinsV1().SSOs(testNamespace).Update(context.Background(), sso, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	env.resync(t)
+	env.events()
+
+	_, err = env.reconcile()
+
+	require.NoError(t, err)
+	redirectURI := "https://sso-golang-http.jx-staging.example.org/oauth2/callback"
+	sso = env.storedSSO(t)
+	assert.Equal(t, []string{"https://sso-golang-http.jx-staging.example.org"}, sso.Status.URLs)
+	assert.Equal(t, []string{redirectURI}, sso.Status.RedirectURIs)
+	assert.Equal(t, "RedirectURIsSynced", condition(sso, v1.SSORedirectURIsSynced).Reason)
+	assert.Equal(t, []string{redirectURI}, env.dex.Client(sso.Status.ClientID).GetRedirectUris())
+	assert.Contains(t, env.proxyConfig(t), fmt.Sprintf("redirect_url = %q", redirectURI))
+	assert.True(t, hasEvent(env.events(), "RedirectURIsSynced"))
+
+	env.resync(t)
+	_, err = env.reconcile()
+	require.NoError(t, err)
+	assert.False(t, hasEvent(env.events(), "RedirectURIsSynced"), "unchanged redirect URIs are not updated again")
+}
+
+// ingressAnnotations returns the annotations of the networking.k8s.io/v1 ingress of the proxy
+func (e *testEnv) ingressAnnotations(t *testing.T) map[string]string {
+	gvr := schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+	obj, err := e.kube.Invokes(k8stesting.NewGetAction(gvr, testNamespace, "golang-http"), nil)
+	require.NoError(t, err)
+	accessor, err := meta.Accessor(obj)
+	require.NoError(t, err)
+	return accessor.GetAnnotations()
+}
+
+func TestReconcileRemovesIngressAnnotations(t *testing.T) {
+	sso := testSSO()
+	sso.Spec.CertIssuerName = "letsencrypt-prod"
+	sso.Spec.IngressAnnotations = map[string]string{"nginx.ingress.kubernetes.io/proxy-body-size": "10m"}
+	env := newTestEnv(t, sso)
+	defer env.close()
+	_, err := env.reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, "letsencrypt-prod", env.ingressAnnotations(t)["cert-manager.io/issuer"])
+	sso = env.storedSSO(t)
+	sso.Spec.CertIssuerName = ""
+	sso.Spec.CertClusterIssuerName = "letsencrypt-cluster"
+	sso.Spec.IngressAnnotations = nil
+	_, err = env.jenkins.JenkinsV1().SSOs(testNamespace).Update(context.Background(), sso, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	env.resync(t)
+
+	_, err = env.reconcile()
+
+	require.NoError(t, err)
+	annotations := env.ingressAnnotations(t)
+	assert.Equal(t, "letsencrypt-cluster", annotations["cert-manager.io/cluster-issuer"])
+	assert.NotContains(t, annotations, "cert-manager.io/issuer")
+	assert.NotContains(t, annotations, "certmanager.k8s.io/issuer")
+	assert.NotContains(t, annotations, "nginx.ingress.kubernetes.io/proxy-body-size")
+}
+
+func TestReconcileWaitsForProxyPods(t *testing.T) {
+	env := newTestEnv(t, testSSO())
+	defer env.close()
+	require.NoError(t, env.kube.CoreV1().Pods(testNamespace).Delete(context.Background(), "proxy", metav1.DeleteOptions{}))
+
+	result, err := env.reconcile()
+
+	require.NoError(t, err)
+	assert.Equal(t, readyRequeueInterval, result.RequeueAfter)
+	sso := env.storedSSO(t)
+	assert.False(t, sso.Status.Initialized)
+	assert.Equal(t, "ProxyNotReady", condition(sso, v1.SSOProxyDeployed).Reason)
+	assert.Equal(t, 1, env.dex.Len(), "the OIDC client is kept until the proxy is ready")
+}
+
+func TestReconcileFailures(t *testing.T) {
+	tests := map[string]struct {
+		change    func(sso *v1.SSO)
+		inject    func(t *testing.T, env *testEnv)
+		condition v1.SSOConditionType
+		reason    string
+		cause     string
+		// rolledBack is true when the OIDC client was created and then deleted from dex
+		rolledBack bool
+	}{
+		"dex client creation fails": {
+			inject: func(t *testing.T, env *testEnv) {
+				env.dex.Intercept(dextest.CreateClient, dextest.Fail(errors.New("injected failure")))
+			},
+			condition: v1.SSODexClientReady,
+			reason:    "DexClientCreateFailed",
+			cause:     "injected failure",
+		},
+		"cookie secret fails": {
+			inject: func(t *testing.T, env *testEnv) {
+				env.kube.PrependReactor("create", "secrets", fail(cookieSecretName(env.sso)))
+			},
+			condition:  v1.SSOProxyDeployed,
+			reason:     "CookieSecretFailed",
+			cause:      "injected failure",
+			rolledBack: true,
+		},
+		"proxy deployment fails": {
+			inject: func(t *testing.T, env *testEnv) {
+				env.kube.PrependReactor("create", "deployments", fail(""))
+			},
+			condition:  v1.SSOProxyDeployed,
+			reason:     "ProxyDeployFailed",
+			cause:      "injected failure",
+			rolledBack: true,
+		},
+		"ingress creation fails": {
+			inject: func(t *testing.T, env *testEnv) {
+				env.kube.PrependReactor("create", "ingresses", fail(""))
+			},
+			condition:  v1.SSOExposed,
+			reason:     "ExposeFailed",
+			cause:      "injected failure",
+			rolledBack: true,
+		},
+		"ingress listing fails": {
+			change: func(sso *v1.SSO) { sso.Spec.SkipExposeService = true },
+			inject: func(t *testing.T, env *testEnv) {
+				env.kube.PrependReactor("list", "ingresses", fail(""))
+			},
+			condition:  v1.SSOExposed,
+			reason:     "IngressNotFound",
+			cause:      "injected failure",
+			rolledBack: true,
+		},
+		"ingress not found": {
+			change:     func(sso *v1.SSO) { sso.Spec.SkipExposeService = true },
+			inject:     func(t *testing.T, env *testEnv) {},
+			condition:  v1.SSOExposed,
+			reason:     "IngressNotFound",
+			cause:      "ingress 'golang-http' not found",
+			rolledBack: true,
+		},
+		"ingress without host": {
+			change: func(sso *v1.SSO) { sso.Spec.SkipExposeService = true },
+			inject: func(t *testing.T, env *testEnv) {
+				ingress := &extensionsv1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "golang-http", Namespace: testNamespace}}
+				_, err := env.kube.ExtensionsV1beta1().Ingresses(testNamespace).Create(context.Background(), ingress, metav1.CreateOptions{})
+				require.NoError(t, err)
+			},
+			condition:  v1.SSOExposed,
+			reason:     "IngressHostNotFound",
+			cause:      "no ingress host found",
+			rolledBack: true,
+		},
+		"dex client update fails": {
+			inject: func(t *testing.T, env *testEnv) {
+				env.dex.Intercept(dextest.UpdateClient, dextest.Fail(errors.New("injected failure")))
+			},
+			condition:  v1.SSORedirectURIsSynced,
+			reason:     "DexClientUpdateFailed",
+			cause:      "injected failure",
+			rolledBack: true,
+		},
+		"dex client deleted during the initialization": {
+			inject: func(t *testing.T, env *testEnv) {
+				env.dex.Intercept(dextest.UpdateClient, dextest.NotFound())
+			},
+			condition:  v1.SSORedirectURIsSynced,
+			reason:     "DexClientUpdateFailed",
+			cause:      "did not find the client",
+			rolledBack: true,
+		},
+		"proxy update fails": {
+			inject: func(t *testing.T, env *testEnv) {
+				env.kube.PrependReactor("update", "deployments", fail(""))
+			},
+			condition:  v1.SSORedirectURIsSynced,
+			reason:     "ProxyUpdateFailed",
+			cause:      "injected failure",
+			rolledBack: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			sso := testSSO()
+			if tc.change != nil {
+				tc.change(sso)
+			}
+			env := newTestEnv(t, sso)
+			defer env.close()
+			tc.inject(t, env)
+
+			_, err := env.reconcile()
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.cause)
+			stored := env.storedSSO(t)
+			assert.Equal(t, v1.SSOPhaseFailed, stored.Status.Phase)
+			assert.False(t, stored.Status.Initialized)
+			assert.Equal(t, tc.reason, condition(stored, tc.condition).Reason)
+			assert.Equal(t, tc.reason, condition(stored, v1.SSOReady).Reason)
+			assert.Equal(t, 0, env.dex.Len(), "the OIDC client is left in dex")
+			events := env.events()
+			assert.Equal(t, tc.rolledBack, hasEvent(events, "RolledBack"))
+			if tc.rolledBack {
+				assert.Empty(t, stored.Status.ClientID)
+				assert.Equal(t, "RolledBack", condition(stored, v1.SSODexClientReady).Reason)
+			}
+		})
+	}
+}
+
+func TestReconcileRollbackFails(t *testing.T) {
+	env := newTestEnv(t, testSSO())
+	defer env.close()
+	env.kube.PrependReactor("create", "deployments", fail(""))
+	env.dex.Intercept(dextest.DeleteClient, nil)
+	env.dex.Intercept(dextest.DeleteClient, dextest.Fail(errors.New("injected failure")))
+
+	_, err := env.reconcile()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Deleteing the OIDC client")
+	stored := env.storedSSO(t)
+	assert.Equal(t, "ProxyDeployFailed", condition(stored, v1.SSOProxyDeployed).Reason)
+	assert.Equal(t, clientID(env.sso), stored.Status.ClientID, "the client which could not be deleted is kept in the status")
+	assert.Equal(t, 1, env.dex.Len())
+	assert.True(t, hasEvent(env.events(), "RollbackFailed"))
+}
+
+func TestReconcileDeletesClientWhenStatusUpdateFails(t *testing.T) {
+	env := newTestEnv(t, testSSO())
+	defer env.close()
+	env.jenkins.PrependReactor("update", "ssos", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sso, ok := action.(k8stesting.UpdateAction).GetObject().(*v1.SSO)
+		if ok && action.GetSubresource() == "status" && sso.Status.Initialized {
+			return true, nil, errors.New("injected failure")
+		}
+		return false, nil, nil
+	})
+
+	_, err := env.reconcile()
+
+	require.Error(t, err)
+	assert.False(t, env.storedSSO(t).Status.Initialized)
+	assert.Equal(t, 0, env.dex.Len())
+	assert.True(t, hasEvent(env.events(), "RolledBack"))
+}
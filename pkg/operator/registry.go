@@ -0,0 +1,121 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+const clientRegistryName = "sso-operator-clients"
+
+// clientRecord links an OIDC client created in the identity provider to the SSO which owns it
+type clientRecord struct {
+	Namespace string      `json:"namespace"`
+	Name      string      `json:"name"`
+	UID       types.UID   `json:"uid"`
+	Created   metav1.Time `json:"created"`
+}
+
+// clientRegistry keeps track in a config map of all OIDC clients created by the operator in the identity provider,
+// since dex does not provide an API to list them
+type clientRegistry struct {
+	namespace string
+}
+
+func newClientRegistry(namespace string) *clientRegistry {
+	return &clientRegistry{
+		namespace: namespace,
+	}
+}
+
+// record registers the OIDC client created for the given SSO
+func (r *clientRegistry) record(clientID string, sso *v1.SSO) error {
+	record := clientRecord{
+		Namespace: sso.GetNamespace(),
+		Name:      sso.GetName(),
+		UID:       sso.GetUID(),
+		Created:   metav1.Now(),
+	}
+	value, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "marshaling client record")
+	}
+	return r.update(func(data map[string]string) {
+		data[clientID] = string(value)
+	})
+}
+
+// forget removes the OIDC client from the registry
+func (r *clientRegistry) forget(clientID string) error {
+	return r.update(func(data map[string]string) {
+		delete(data, clientID)
+	})
+}
+
+// list returns all OIDC clients known by the registry indexed by client ID
+func (r *clientRegistry) list() (map[string]clientRecord, error) {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting k8s client")
+	}
+	records := map[string]clientRecord{}
+	configMap, err := k8sClient.CoreV1().ConfigMaps(r.namespace).Get(context.TODO(), clientRegistryName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return records, nil
+		}
+		return nil, errors.Wrap(err, "getting the client registry config map")
+	}
+	for clientID, value := range configMap.Data {
+		record := clientRecord{}
+		err := json.Unmarshal([]byte(value), &record)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unmarshaling the record of client '%s'", clientID)
+		}
+		records[clientID] = record
+	}
+	return records, nil
+}
+
+func (r *clientRegistry) update(change func(data map[string]string)) error {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+	configMaps := k8sClient.CoreV1().ConfigMaps(r.namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(context.TODO(), clientRegistryName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      clientRegistryName,
+					Namespace: r.namespace,
+				},
+				Data: map[string]string{},
+			}
+			change(configMap.Data)
+			_, err = configMaps.Create(context.TODO(), configMap, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				return apierrors.NewConflict(corev1.Resource("configmaps"), clientRegistryName, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		change(configMap.Data)
+		_, err = configMaps.Update(context.TODO(), configMap, metav1.UpdateOptions{})
+		return err
+	})
+}
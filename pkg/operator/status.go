@@ -0,0 +1,89 @@
+package operator
+
+import (
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// setCondition adds or updates the condition of the given type, the transition time is only changed
+// when the status of the condition changes
+func setCondition(status *v1.SSOStatus, condType v1.SSOConditionType, condStatus corev1.ConditionStatus, reason string, message string) {
+	condition := v1.SSOCondition{
+		Type:               condType,
+		Status:             condStatus,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+	for i, c := range status.Conditions {
+		if c.Type != condType {
+			continue
+		}
+		if c.Status == condStatus {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+		status.Conditions[i] = condition
+		return
+	}
+	status.Conditions = append(status.Conditions, condition)
+}
+
+// setReady marks the SSO as ready
+func setReady(status *v1.SSOStatus) {
+	setCondition(status, v1.SSOReady, corev1.ConditionTrue, "Ready", "SSO proxy is ready")
+	status.Phase = v1.SSOPhaseReady
+}
+
+// setFailed marks the condition of the given type as failed together with the readiness of the SSO
+func setFailed(status *v1.SSOStatus, condType v1.SSOConditionType, reason string, err error) {
+	setCondition(status, condType, corev1.ConditionFalse, reason, err.Error())
+	setCondition(status, v1.SSOReady, corev1.ConditionFalse, reason, err.Error())
+	status.Phase = v1.SSOPhaseFailed
+}
+
+// updateStatus writes the status of the SSO if it changed since it was last stored, the SSO is updated
+// in place with the server's representation
+func updateStatus(sso *v1.SSO, previous *v1.SSOStatus) error {
+	if equality.Semantic.DeepEqual(sso.Status, *previous) {
+		return nil
+	}
+	updated, err := kubernetes.UpdateSSOStatus(sso)
+	if err != nil {
+		return errors.Wrapf(err, "updating '%s' SSO status", sso.GetName())
+	}
+	updated.Status.DeepCopyInto(previous)
+	updated.DeepCopyInto(sso)
+	return nil
+}
+
+// recordStatus writes the progress of the SSO in its status, a failure is only logged since the
+// status is written again at the end of the reconciliation
+func recordStatus(sso *v1.SSO, previous *v1.SSOStatus) {
+	err := updateStatus(sso, previous)
+	if err != nil {
+		logrus.Warnf("failed to record the progress in the status of SSO '%s': %v", sso.GetName(), err)
+	}
+}
+
+// failStatus records a failure in the status of the SSO and returns the cause
+func failStatus(sso *v1.SSO, previous *v1.SSOStatus, condType v1.SSOConditionType, reason string, cause error) error {
+	setFailed(&sso.Status, condType, reason, cause)
+	err := updateStatus(sso, previous)
+	if err != nil {
+		logrus.Errorf("failed to record the failure in the status of SSO '%s': %v", sso.GetName(), err)
+	}
+	return cause
+}
+
+// waitStatus records in the status of the SSO the resource which is not ready yet and returns the cause
+func waitStatus(sso *v1.SSO, previous *v1.SSOStatus, condType v1.SSOConditionType, condStatus corev1.ConditionStatus,
+	reason string, cause error) error {
+	setCondition(&sso.Status, condType, condStatus, reason, cause.Error())
+	recordStatus(sso, previous)
+	return cause
+}
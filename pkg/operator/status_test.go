@@ -0,0 +1,44 @@
+package operator
+
+import (
+	"errors"
+	"testing"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetConditionKeepsTransitionTime(t *testing.T) {
+	transitionTime := metav1.Unix(1000, 0)
+	status := &v1.SSOStatus{
+		Conditions: []v1.SSOCondition{{
+			Type:               v1.SSOProxyDeployed,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: transitionTime,
+			Reason:             "ProxyDeployed",
+		}},
+	}
+
+	setCondition(status, v1.SSOProxyDeployed, corev1.ConditionTrue, "ProxyDeployed", "deployed again")
+
+	assert.Len(t, status.Conditions, 1)
+	assert.Equal(t, transitionTime, status.Conditions[0].LastTransitionTime)
+	assert.Equal(t, "deployed again", status.Conditions[0].Message)
+}
+
+func TestSetFailed(t *testing.T) {
+	status := &v1.SSOStatus{}
+	setReady(status)
+
+	setFailed(status, v1.SSOExposed, "ExposeFailed", errors.New("expose job timeout"))
+
+	assert.Equal(t, v1.SSOPhaseFailed, status.Phase)
+	assert.Len(t, status.Conditions, 2)
+	for _, c := range status.Conditions {
+		assert.Equal(t, corev1.ConditionFalse, c.Status)
+		assert.Equal(t, "ExposeFailed", c.Reason)
+		assert.Equal(t, "expose job timeout", c.Message)
+	}
+}
@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"context"
+	"path/filepath"
+
+	apiv1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/idp"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CurrentClient returns the OIDC client configured in the oauth2_proxy of the SSO
+func CurrentClient(sso *apiv1.SSO) (*idp.Client, error) {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting k8s client")
+	}
+	secret, err := k8sClient.CoreV1().Secrets(sso.GetNamespace()).Get(context.TODO(), buildName(sso.GetName(), configSecretName), metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting oauth2_proxy secret")
+	}
+	return parseClientConfig(string(secret.Data[filepath.Base(configPath)]))
+}
+
+// UpdateClient replaces the OIDC client in the oauth2_proxy secret and restarts the proxy
+func UpdateClient(sso *apiv1.SSO, client *idp.Client, cookieSecret string) error {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+	secret, err := k8sClient.CoreV1().Secrets(sso.GetNamespace()).Get(context.TODO(), buildName(sso.GetName(), configSecretName), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "getting oauth2_proxy secret")
+	}
+	secret.Data = nil
+	err = updateProxySecret(secret, sso, client, cookieSecret)
+	if err != nil {
+		return errors.Wrap(err, "updating oauth2_proxy secret")
+	}
+
+	// The deployment is restarted by the reconciliation since the version of its secret changed
+	_, _, err = Reconcile(sso, cookieSecret)
+	if err != nil {
+		return errors.Wrap(err, "restarting oauth2_proxy")
+	}
+	return nil
+}
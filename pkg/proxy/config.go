@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"bytes"
+	"strconv"
 	"text/template"
 
 	"github.com/pkg/errors"
@@ -30,9 +31,13 @@ type Config struct {
 	LoginURL      string
 	RedeemURL     string
 
-	Upstream     string
+	Upstreams    []string
 	ForwardToken bool
 
+	EmailDomains            []string
+	AllowedGroups           []string
+	AuthenticatedEmailsFile string
+
 	Cookie Cookie
 }
 
@@ -49,7 +54,9 @@ redeem_url = "{{.RedeemURL}}"
 
 ## the http url(s) of the upstream endpoint. If multiple, routing is based on path
 upstreams = [
-    "{{.Upstream}}"
+{{- range $i, $upstream := .Upstreams}}{{if $i}},{{end}}
+    {{quote $upstream}}
+{{- end}}
 ]
 
 ## Log requests to stdout
@@ -65,9 +72,27 @@ pass_host_header = false
 pass_access_token = {{.ForwardToken}}
 
 ## Email Domains to allow authentication for (this authorizes any email on this domain)
+{{- if .EmailDomains}}
 email_domains = [
-     "*"
+{{- range $i, $domain := .EmailDomains}}{{if $i}},{{end}}
+     {{quote $domain}}
+{{- end}}
+ ]
+{{- end}}
+{{- if .AuthenticatedEmailsFile}}
+
+## File with the emails of the users allowed to authenticate in addition to the email domains
+authenticated_emails_file = "{{.AuthenticatedEmailsFile}}"
+{{- end}}
+{{- if .AllowedGroups}}
+
+## Groups of the OIDC provider allowed to authenticate
+allowed_groups = [
+{{- range $i, $group := .AllowedGroups}}{{if $i}},{{end}}
+     {{quote $group}}
+{{- end}}
  ]
+{{- end}}
 
 ## Cookie Settings
 ## Name     - the cookie name
@@ -98,7 +123,8 @@ skip_provider_button = true
 `
 
 func renderConfig(config *Config) (string, error) {
-	tmpl := template.New("oauth2_proxy.tpl")
+	// The allow-lists and the upstream paths come from the SSO spec, they are escaped as TOML basic strings
+	tmpl := template.New("oauth2_proxy.tpl").Funcs(template.FuncMap{"quote": strconv.Quote})
 
 	tmpl, err := tmpl.Parse(proxyConfigTemplate)
 	if err != nil {
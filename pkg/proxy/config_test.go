@@ -15,7 +15,7 @@ func TestProxyConfig(t *testing.T) {
 		RedirectURL:   "http://test-proxy/calback",
 		LoginURL:      "http://test-proxy/auth",
 		RedeemURL:     "http://test-proxy/token",
-		Upstream:      "http://test-upstream",
+		Upstreams:     []string{"http://test-upstream"},
 		ForwardToken:  false,
 		Cookie: Cookie{
 			Name:     "test-cookie",
@@ -33,3 +33,75 @@ func TestProxyConfig(t *testing.T) {
 	assert.NoError(t, err, "should render proxy config without error")
 	assert.NotEmpty(t, strConfig, "proxy config should not be empty")
 }
+
+func TestProxyConfigMultipleUpstreams(t *testing.T) {
+	config := &Config{
+		Port:      4180,
+		Upstreams: []string{"http://test-ui:80/", "http://test-api:8080/api/"},
+	}
+
+	strConfig, err := renderConfig(config)
+
+	assert.NoError(t, err, "should render proxy config without error")
+	assert.Contains(t, strConfig, "upstreams = [\n    \"http://test-ui:80/\",\n    \"http://test-api:8080/api/\"\n]")
+}
+
+func TestProxyConfigAllowLists(t *testing.T) {
+	config := &Config{
+		Port:                    4180,
+		Upstreams:               []string{"http://test-upstream"},
+		EmailDomains:            []string{"example.com", "example.org"},
+		AllowedGroups:           []string{"admins"},
+		AuthenticatedEmailsFile: emailsPath,
+	}
+
+	strConfig, err := renderConfig(config)
+
+	assert.NoError(t, err, "should render proxy config without error")
+	assert.Contains(t, strConfig, "email_domains = [\n     \"example.com\",\n     \"example.org\"\n ]")
+	assert.Contains(t, strConfig, "allowed_groups = [\n     \"admins\"\n ]")
+	assert.Contains(t, strConfig, "authenticated_emails_file = \"/config/authenticated-emails.txt\"")
+}
+
+func TestProxyConfigWithoutAllowLists(t *testing.T) {
+	config := &Config{
+		Port:      4180,
+		Upstreams: []string{"http://test-upstream"},
+	}
+
+	strConfig, err := renderConfig(config)
+
+	assert.NoError(t, err, "should render proxy config without error")
+	assert.NotContains(t, strConfig, "email_domains")
+	assert.NotContains(t, strConfig, "allowed_groups")
+	assert.NotContains(t, strConfig, "authenticated_emails_file")
+}
+
+func TestProxyConfigEscapesAllowLists(t *testing.T) {
+	config := &Config{
+		Port:          4180,
+		Upstreams:     []string{"http://test-upstream"},
+		EmailDomains:  []string{`example.com"]` + "\nskip_auth_regex = [\".*\""},
+		AllowedGroups: []string{`admins\"`},
+	}
+
+	strConfig, err := renderConfig(config)
+
+	assert.NoError(t, err, "should render proxy config without error")
+	assert.Contains(t, strConfig, `"example.com\"]\nskip_auth_regex = [\".*\""`)
+	assert.Contains(t, strConfig, `"admins\\\""`)
+	assert.NotContains(t, strConfig, "\nskip_auth_regex")
+}
+
+func TestProxyConfigEscapesUpstreams(t *testing.T) {
+	config := &Config{
+		Port:      4180,
+		Upstreams: []string{"http://test-api:8080/api\"]\nskip_auth_regex = [\".*/"},
+	}
+
+	strConfig, err := renderConfig(config)
+
+	assert.NoError(t, err, "should render proxy config without error")
+	assert.Contains(t, strConfig, `"http://test-api:8080/api\"]\nskip_auth_regex = [\".*/"`)
+	assert.NotContains(t, strConfig, "\nskip_auth_regex")
+}
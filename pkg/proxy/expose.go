@@ -1,19 +1,19 @@
 package proxy
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
-	"time"
 
 	apiv1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
-	"github.com/operator-framework/operator-sdk/pkg/sdk"
 	"github.com/pkg/errors"
 	yaml "gopkg.in/yaml.v2"
 	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -25,115 +25,77 @@ const (
 	exposeConfigMapName    = "expose-configmap"
 	exposeEnv              = "KUBERNETES_NAMESPACE"
 	exposer                = "Ingress"
-	exposeTimeout          = time.Duration(5 * time.Minute)
-	exposeCheckInterval    = time.Duration(10 * time.Second)
-	cleanupeTimeout        = time.Duration(2 * time.Minute)
-	cleanupCheckInterval   = time.Duration(10 * time.Second)
 )
 
-// Expose executes the exposecontroller as a Job in order publicly expose the SSO service
+// Expose executes the exposecontroller as a Job in order publicly expose the SSO service, a NotReadyError is
+// returned until the job completes
 func Expose(sso *apiv1.SSO, serviceName string, serviceAccount string) error {
 	configMap, err := exposeConfigMap(sso, serviceName)
 	if err != nil {
 		return errors.Wrap(err, "building expose config map")
 	}
 	configMap.SetOwnerReferences(append(configMap.GetOwnerReferences(), ownerRef(sso)))
-	err = sdk.Create(configMap)
-	if err != nil && !apierrors.IsAlreadyExists(err) {
-		return errors.Wrap(err, "creating expose config map")
-	}
-
-	job := createJob("expose", sso, serviceAccount, exposeContainer(sso))
+	job := createJob("expose", sso, serviceAccount, exposeContainer(sso), configMap.GetName())
 	job.SetOwnerReferences(append(job.GetOwnerReferences(), ownerRef(sso)))
-	err = sdk.Create(job)
-	if err != nil {
-		msg := "creating expose job"
-		if apierrors.IsAlreadyExists(err) {
-			errdel := sdk.Delete(job)
-			if errdel != nil {
-				return errors.Wrapf(errdel, "%s: deleting existing expose job", msg)
-			}
-		}
-		return errors.Wrap(err, msg)
-	}
-
-	k8sClient, err := kubernetes.GetClientset()
-	if err != nil {
-		return errors.Wrap(err, "getting k8s client")
-	}
-	err = kubernetes.WaitForJobComplete(k8sClient, sso.GetNamespace(), job.GetName(), exposeCheckInterval, exposeTimeout)
-	if err != nil {
-		return errors.Wrap(err, "waiting for SSO to be exposed")
-	}
+	return runJob(sso, job, configMap)
+}
 
-	deletePropagation := metav1.DeletePropagationBackground
-	deleteOption := &metav1.DeleteOptions{
-		PropagationPolicy: &deletePropagation,
-	}
-	err = sdk.Delete(job, sdk.WithDeleteOptions(deleteOption))
+// Cleanup executes the exposecontroller as a job to cleanup the ingress resources, a NotReadyError is returned
+// until the job completes
+func Cleanup(sso *apiv1.SSO, serviceName string, serviceAccount string) error {
+	configMap, err := exposeConfigMap(sso, serviceName)
 	if err != nil {
-		return errors.Wrap(err, "cleaning up the expose job")
+		return errors.Wrap(err, "building cleanup config map")
 	}
+	job := createJob("cleanup", sso, serviceAccount, cleanupContainer(sso, serviceName), configMap.GetName())
+	return runJob(sso, job, configMap)
+}
 
-	err = sdk.Delete(configMap)
+// runJob starts the job with its config map, or checks the progress of the job started by a previous
+// reconciliation. The job and the config map are deleted once the job completed or failed.
+func runJob(sso *apiv1.SSO, job *batchv1.Job, configMap *v1.ConfigMap) error {
+	c, err := kubernetes.GetClient()
 	if err != nil {
-		return errors.Wrap(err, "cleaning up the expose config map")
+		return errors.Wrap(err, "getting controller-runtime client")
 	}
-
-	return nil
-}
-
-// Cleanup executes the exposecontroller as a job to cleanup the ingress resources
-func Cleanup(sso *apiv1.SSO, serviceName string, serviceAccount string) error {
-	configMap, err := exposeConfigMap(sso, serviceName)
+	err = c.Create(context.TODO(), configMap)
 	if err != nil && !apierrors.IsAlreadyExists(err) {
-		return errors.Wrap(err, "building cleanup config map")
-	}
-	err = sdk.Create(configMap)
-	if err != nil && apierrors.IsAlreadyExists(err) {
-		return errors.Wrap(err, "creating cleanup config map")
+		return errors.Wrapf(err, "creating config map '%s'", configMap.GetName())
 	}
 
-	job := createJob("cleanup", sso, serviceAccount, cleanupContainer(sso, serviceName))
-	err = sdk.Create(job)
-	if err != nil {
-		msg := "creating cleanup job"
-		if apierrors.IsAlreadyExists(err) {
-			errdel := sdk.Delete(job)
-			if errdel != nil {
-				return errors.Wrapf(errdel, "%s: deleting existing cleanup job", msg)
-			}
-		}
-		return errors.Wrap(err, msg)
+	err = c.Create(context.TODO(), job)
+	if err == nil {
+		return notReady("job '%s' started", job.GetName())
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "creating job '%s'", job.GetName())
 	}
 
 	k8sClient, err := kubernetes.GetClientset()
 	if err != nil {
 		return errors.Wrap(err, "getting k8s client")
 	}
-	err = kubernetes.WaitForJobComplete(k8sClient, sso.GetNamespace(), job.GetName(), cleanupCheckInterval, cleanupeTimeout)
-	if err != nil {
-		return errors.Wrap(err, "waiting for SSO to be cleaned up")
+	complete, jobErr := kubernetes.JobComplete(k8sClient, sso.GetNamespace(), job.GetName())
+	if jobErr == nil && !complete {
+		return notReady("job '%s' is running", job.GetName())
 	}
 
-	deletePropagation := metav1.DeletePropagationBackground
-	deleteOption := &metav1.DeleteOptions{
-		PropagationPolicy: &deletePropagation,
+	err = c.Delete(context.TODO(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "deleting job '%s'", job.GetName())
 	}
-	err = sdk.Delete(job, sdk.WithDeleteOptions(deleteOption))
-	if err != nil {
-		return errors.Wrap(err, "deleting the cleanup job")
+	if jobErr != nil {
+		return errors.Wrapf(jobErr, "waiting for job '%s'", job.GetName())
 	}
 
-	err = sdk.Delete(configMap)
-	if err != nil {
-		return errors.Wrap(err, "deleting the cleanup config map")
+	err = c.Delete(context.TODO(), configMap)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "deleting config map '%s'", configMap.GetName())
 	}
-
 	return nil
 }
 
-func createJob(name string, sso *apiv1.SSO, serviceAccount string, container *v1.Container) *batchv1.Job {
+func createJob(name string, sso *apiv1.SSO, serviceAccount string, container *v1.Container, configMapName string) *batchv1.Job {
 	ns := sso.GetNamespace()
 	jobName := buildName(sso.GetName(), name)
 
@@ -150,7 +112,7 @@ func createJob(name string, sso *apiv1.SSO, serviceAccount string, container *v1
 				VolumeSource: v1.VolumeSource{
 					ConfigMap: &v1.ConfigMapVolumeSource{
 						LocalObjectReference: v1.LocalObjectReference{
-							Name: exposeConfigMapName,
+							Name: configMapName,
 						},
 					},
 				},
@@ -234,7 +196,7 @@ func exposeConfigMap(sso *apiv1.SSO, serviceName string) (*v1.ConfigMap, error)
 			Kind:       "ConfigMap",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      exposeConfigMapName,
+			Name:      buildName(sso.GetName(), exposeConfigMapName),
 			Namespace: sso.GetNamespace(),
 		},
 		Data: map[string]string{
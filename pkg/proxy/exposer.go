@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	apiv1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+)
+
+// IsExposeController checks if the SSO proxy is exposed by an exposecontroller job rather than by a resource
+// owned by the SSO
+func IsExposeController(sso *apiv1.SSO) bool {
+	return sso.Spec.Exposer == "" || sso.Spec.Exposer == apiv1.ExposerExposeController
+}
+
+// ExposeOwned publicly exposes the proxy service with a resource owned by the SSO according to its exposer, and
+// returns the public hosts of the proxy
+func ExposeOwned(sso *apiv1.SSO, serviceName string, appName string) ([]string, error) {
+	hosts, _, err := ensureExposure(sso, serviceName, appName)
+	return hosts, err
+}
+
+// DeleteOwned deletes the resource which exposes the proxy service, the ingress is left to the garbage collector
+// since its name depends on the labels of the upstream service
+func DeleteOwned(sso *apiv1.SSO) error {
+	switch sso.Spec.Exposer {
+	case apiv1.ExposerHTTPRoute:
+		return deleteResource(httpRouteAPIVersion, httpRouteKind, sso.GetNamespace(), sso.GetName())
+	case apiv1.ExposerRoute:
+		return deleteResource(routeAPIVersion, routeKind, sso.GetNamespace(), sso.GetName())
+	}
+	return nil
+}
+
+// ensureExposure creates or updates the resource which exposes the proxy service. It returns the public hosts
+// of the proxy, and true when the resource was changed.
+func ensureExposure(sso *apiv1.SSO, serviceName string, appName string) ([]string, bool, error) {
+	switch sso.Spec.Exposer {
+	case apiv1.ExposerIngress:
+		return ensureIngress(sso, serviceName, appName)
+	case apiv1.ExposerHTTPRoute:
+		return ensureHTTPRoute(sso, serviceName, appName)
+	case apiv1.ExposerRoute:
+		return ensureRoute(sso, serviceName, appName)
+	}
+	return nil, false, errors.Errorf("unknown exposer '%s'", sso.Spec.Exposer)
+}
+
+// ExposerResource identifies the API of a resource which exposes the proxy service
+type ExposerResource struct {
+	APIVersion string
+	Kind       string
+}
+
+// ExposerResources returns the APIs of the resources owned by a SSO which expose its proxy service
+func ExposerResources() []ExposerResource {
+	return []ExposerResource{
+		{APIVersion: ingressAPIVersion, Kind: ingressKind},
+		{APIVersion: httpRouteAPIVersion, Kind: httpRouteKind},
+		{APIVersion: routeAPIVersion, Kind: routeKind},
+	}
+}
@@ -0,0 +1,211 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	apiv1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	ingressAPIVersion  = "networking.k8s.io/v1"
+	ingressKind        = "Ingress"
+	defaultURLTemplate = "{{.Service}}.{{.Namespace}}.{{.Domain}}"
+
+	// ownedAnnotationsAnnotation lists the annotations set by the operator on a resource which exposes the proxy
+	ownedAnnotationsAnnotation = "jenkins.io/sso-owned-annotations"
+)
+
+// ensureIngress creates the ingress of the proxy service or updates it when it differs from the SSO spec. It
+// returns the ingress hosts, and true when the ingress was changed.
+func ensureIngress(sso *apiv1.SSO, serviceName string, appName string) ([]string, bool, error) {
+	host, err := ingressHost(sso, serviceName)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "building the ingress host")
+	}
+	desired := proxyIngress(sso, serviceName, appName, host)
+	_, changed, err := ensureResource(desired)
+	if err != nil {
+		return nil, false, err
+	}
+	return []string{host}, changed, nil
+}
+
+// ensureResource creates the given resource or updates its spec and annotations when they differ from the
+// current resource. The annotations owned by the operator which are no longer desired are removed, the other
+// annotations are kept. It returns the resource stored in the cluster, and true when it was changed.
+func ensureResource(desired *unstructured.Unstructured) (*unstructured.Unstructured, bool, error) {
+	kind := strings.ToLower(desired.GetKind())
+	c, err := kubernetes.GetClient()
+	if err != nil {
+		return nil, false, errors.Wrap(err, "getting controller-runtime client")
+	}
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(desired.GroupVersionKind())
+	key := types.NamespacedName{Namespace: desired.GetNamespace(), Name: desired.GetName()}
+	err = c.Get(context.TODO(), key, current)
+	if apierrors.IsNotFound(err) {
+		logrus.Infof("Creating %s '%s'", kind, desired.GetName())
+		desired.SetAnnotations(exposerAnnotations(desired.GetAnnotations(), nil))
+		err = c.Create(context.TODO(), desired)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "creating %s '%s'", kind, desired.GetName())
+		}
+		return desired, true, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "getting %s '%s'", kind, desired.GetName())
+	}
+
+	// The spec is compared with DeepDerivative since the API server defaults some of its fields
+	annotations := exposerAnnotations(desired.GetAnnotations(), current.GetAnnotations())
+	if equality.Semantic.DeepDerivative(desired.Object["spec"], current.Object["spec"]) &&
+		equality.Semantic.DeepEqual(annotations, current.GetAnnotations()) {
+		return current, false, nil
+	}
+	logrus.Infof("Updating %s '%s'", kind, desired.GetName())
+	current.SetAnnotations(annotations)
+	current.Object["spec"] = desired.Object["spec"]
+	err = c.Update(context.TODO(), current)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "updating %s '%s'", kind, desired.GetName())
+	}
+	return current, true, nil
+}
+
+// exposerAnnotations returns the current annotations of a resource which exposes the proxy with the annotations
+// owned by the operator replaced by the desired ones. The keys of the desired annotations are recorded in the
+// owned annotations annotation, so that an annotation removed from the SSO is removed from the resource. The
+// cert-manager annotations are always owned, they were set before the owned annotations were recorded.
+func exposerAnnotations(desired map[string]string, current map[string]string) map[string]string {
+	annotations := map[string]string{}
+	for k, v := range current {
+		annotations[k] = v
+	}
+	owned := []string{oldCertManagerAnnotation, certManagerAnnotation, oldClusterIssuerAnnotation, clusterIssuerAnnotation}
+	if recorded := current[ownedAnnotationsAnnotation]; recorded != "" {
+		owned = append(owned, strings.Split(recorded, ",")...)
+	}
+	for _, k := range owned {
+		delete(annotations, k)
+	}
+	delete(annotations, ownedAnnotationsAnnotation)
+
+	keys := []string{}
+	for k, v := range desired {
+		annotations[k] = v
+		keys = append(keys, k)
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		annotations[ownedAnnotationsAnnotation] = strings.Join(keys, ",")
+	}
+	return annotations
+}
+
+// deleteResource deletes the resource with the given name, a resource which does not exist is ignored
+func deleteResource(apiVersion string, kind string, namespace string, name string) error {
+	c, err := kubernetes.GetClient()
+	if err != nil {
+		return errors.Wrap(err, "getting controller-runtime client")
+	}
+	resource := &unstructured.Unstructured{}
+	resource.SetAPIVersion(apiVersion)
+	resource.SetKind(kind)
+	resource.SetNamespace(namespace)
+	resource.SetName(name)
+	err = c.Delete(context.TODO(), resource)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "deleting %s '%s'", strings.ToLower(kind), name)
+	}
+	return nil
+}
+
+// ingressHost renders the host of the proxy service from the URL template of the SSO
+func ingressHost(sso *apiv1.SSO, serviceName string) (string, error) {
+	if sso.Spec.Domain == "" {
+		return "", errors.New("the domain is required to expose the SSO proxy with an ingress")
+	}
+	urlTemplate := sso.Spec.URLTemplate
+	if urlTemplate == "" {
+		urlTemplate = defaultURLTemplate
+	}
+	t, err := template.New("host").Parse(urlTemplate)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing URL template %q", urlTemplate)
+	}
+	var host bytes.Buffer
+	err = t.Execute(&host, map[string]string{
+		"Service":   serviceName,
+		"Namespace": sso.GetNamespace(),
+		"Domain":    sso.Spec.Domain,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "rendering URL template %q", urlTemplate)
+	}
+	return host.String(), nil
+}
+
+// proxyIngress builds the networking.k8s.io/v1 ingress of the proxy service, the API types of this version are
+// not available in the vendored Kubernetes API so the ingress is built as an unstructured object
+func proxyIngress(sso *apiv1.SSO, serviceName string, appName string, host string) *unstructured.Unstructured {
+	annotations := map[string]string{}
+	for _, annotation := range ingressAnnotations(sso) {
+		annotations[annotation.key] = annotation.value
+	}
+
+	ingress := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"ingressClassName": getIngressClass(sso),
+				"tls": []interface{}{
+					map[string]interface{}{
+						"hosts":      []interface{}{host},
+						"secretName": fmt.Sprintf("tls-%s", appName),
+					},
+				},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"host": host,
+						"http": map[string]interface{}{
+							"paths": []interface{}{
+								map[string]interface{}{
+									"path":     "/",
+									"pathType": "Prefix",
+									"backend": map[string]interface{}{
+										"service": map[string]interface{}{
+											"name": serviceName,
+											"port": map[string]interface{}{
+												"number": int64(publicPort),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	ingress.SetAPIVersion(ingressAPIVersion)
+	ingress.SetKind(ingressKind)
+	ingress.SetName(appName)
+	ingress.SetNamespace(sso.GetNamespace())
+	ingress.SetLabels(labels(sso, appName))
+	ingress.SetAnnotations(annotations)
+	ingress.SetOwnerReferences([]metav1.OwnerReference{ownerRef(sso)})
+	return ingress
+}
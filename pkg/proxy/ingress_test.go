@@ -0,0 +1,150 @@
+package proxy
+
+import (
+	"testing"
+
+	apiv1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testSSO() *apiv1.SSO {
+	return &apiv1.SSO{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sso-golang-http",
+			Namespace: "jx-staging",
+			UID:       "0c6bd87e-9b4e-11e8-a1a9-42010a840052",
+		},
+		Spec: apiv1.SSOSpec{
+			Domain:         "example.com",
+			CertIssuerName: "letsencrypt-prod",
+			Exposer:        apiv1.ExposerIngress,
+		},
+	}
+}
+
+func TestIngressHost(t *testing.T) {
+	sso := testSSO()
+
+	host, err := ingressHost(sso, "sso-golang-http")
+	assert.NoError(t, err)
+	assert.Equal(t, "sso-golang-http.jx-staging.example.com", host)
+
+	sso.Spec.URLTemplate = "{{.Service}}-{{.Namespace}}.{{.Domain}}"
+	host, err = ingressHost(sso, "sso-golang-http")
+	assert.NoError(t, err)
+	assert.Equal(t, "sso-golang-http-jx-staging.example.com", host)
+
+	sso.Spec.Domain = ""
+	_, err = ingressHost(sso, "sso-golang-http")
+	assert.Error(t, err, "a domain should be required")
+}
+
+func TestProxyIngress(t *testing.T) {
+	sso := testSSO()
+
+	ingress := proxyIngress(sso, "sso-golang-http", "golang-http", "sso-golang-http.jx-staging.example.com")
+
+	assert.Equal(t, "networking.k8s.io/v1", ingress.GetAPIVersion())
+	assert.Equal(t, "golang-http", ingress.GetName())
+	assert.Equal(t, "letsencrypt-prod", ingress.GetAnnotations()[certManagerAnnotation])
+	assert.Len(t, ingress.GetOwnerReferences(), 1)
+	assert.Equal(t, sso.GetUID(), ingress.GetOwnerReferences()[0].UID)
+
+	className, _, _ := unstructured.NestedString(ingress.Object, "spec", "ingressClassName")
+	assert.Equal(t, ingressClass, className)
+	rules, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "rules")
+	assert.Len(t, rules, 1)
+	host, _, _ := unstructured.NestedString(rules[0].(map[string]interface{}), "host")
+	assert.Equal(t, "sso-golang-http.jx-staging.example.com", host)
+	tls, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "tls")
+	secretName, _, _ := unstructured.NestedString(tls[0].(map[string]interface{}), "secretName")
+	assert.Equal(t, "tls-golang-http", secretName)
+}
+
+func TestServiceAnnotations(t *testing.T) {
+	sso := testSSO()
+	sso.Spec.CertIssuerName = ""
+	sso.Spec.CertClusterIssuerName = "letsencrypt-cluster"
+	sso.Spec.IngressClassName = "traefik"
+	sso.Spec.IngressAnnotations = map[string]string{
+		"nginx.ingress.kubernetes.io/proxy-body-size": "8m",
+		"nginx.ingress.kubernetes.io/limit-rps":       "10",
+	}
+
+	annotations, err := serviceAnnotations(sso, "golang-http")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "cert-manager.io/cluster-issuer: letsencrypt-cluster\n"+
+		"certmanager.k8s.io/cluster-issuer: letsencrypt-cluster\n"+
+		"kubernetes.io/ingress.class: traefik\n"+
+		"nginx.ingress.kubernetes.io/limit-rps: \"10\"\n"+
+		"nginx.ingress.kubernetes.io/proxy-body-size: 8m\n", annotations[exposeIngressAnnotation])
+}
+
+func TestServiceAnnotationsMultiLineValue(t *testing.T) {
+	sso := testSSO()
+	snippet := "more_set_headers \"X-Frame-Options: DENY\";\n# comment\nadd_header X-Debug on;\n"
+	sso.Spec.IngressAnnotations = map[string]string{
+		"nginx.ingress.kubernetes.io/configuration-snippet": snippet,
+		"example.com/note": "key: value # not a comment",
+	}
+
+	annotations, err := serviceAnnotations(sso, "golang-http")
+
+	assert.NoError(t, err)
+	parsed := map[string]string{}
+	err = yaml.Unmarshal([]byte(annotations[exposeIngressAnnotation]), &parsed)
+	assert.NoError(t, err, "the ingress annotations should be valid YAML")
+	assert.Equal(t, snippet, parsed["nginx.ingress.kubernetes.io/configuration-snippet"])
+	assert.Equal(t, "key: value # not a comment", parsed["example.com/note"])
+	assert.Equal(t, "nginx", parsed[ingressClassAnnotations])
+	assert.Len(t, parsed, len(sso.Spec.IngressAnnotations)+3, "no other keys should be injected")
+}
+
+func TestProxyIngressCustomAnnotations(t *testing.T) {
+	sso := testSSO()
+	sso.Spec.IngressClassName = "haproxy"
+	sso.Spec.IngressAnnotations = map[string]string{"haproxy.org/rate-limit-requests": "10"}
+
+	ingress := proxyIngress(sso, "sso-golang-http", "golang-http", "sso-golang-http.jx-staging.example.com")
+
+	className, _, _ := unstructured.NestedString(ingress.Object, "spec", "ingressClassName")
+	assert.Equal(t, "haproxy", className)
+	assert.Equal(t, "10", ingress.GetAnnotations()["haproxy.org/rate-limit-requests"])
+	assert.Equal(t, "letsencrypt-prod", ingress.GetAnnotations()[certManagerAnnotation])
+}
+
+func TestExposerAnnotations(t *testing.T) {
+	created := exposerAnnotations(map[string]string{
+		certManagerAnnotation:                         "letsencrypt-prod",
+		"nginx.ingress.kubernetes.io/proxy-body-size": "10m",
+	}, nil)
+	assert.Equal(t, "cert-manager.io/issuer,nginx.ingress.kubernetes.io/proxy-body-size", created[ownedAnnotationsAnnotation])
+
+	current := map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"}
+	for k, v := range created {
+		current[k] = v
+	}
+	updated := exposerAnnotations(map[string]string{clusterIssuerAnnotation: "letsencrypt-prod"}, current)
+
+	assert.Equal(t, map[string]string{
+		"kubectl.kubernetes.io/last-applied-configuration": "{}",
+		clusterIssuerAnnotation:                            "letsencrypt-prod",
+		ownedAnnotationsAnnotation:                         clusterIssuerAnnotation,
+	}, updated, "the annotations removed from the SSO are removed, the others are kept")
+}
+
+func TestExposerAnnotationsBeforeOwnedAnnotationsRecorded(t *testing.T) {
+	current := map[string]string{
+		oldCertManagerAnnotation: "letsencrypt-prod",
+		certManagerAnnotation:    "letsencrypt-prod",
+		"example.com/team":       "platform",
+	}
+
+	updated := exposerAnnotations(map[string]string{}, current)
+
+	assert.Equal(t, map[string]string{"example.com/team": "platform"}, updated)
+}
@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	apiv1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	routeAPIVersion = "route.openshift.io/v1"
+	routeKind       = "Route"
+)
+
+// ensureRoute creates the OpenShift route of the proxy service or updates it when it differs from the SSO spec. The
+// host is generated by OpenShift when the SSO has no domain. It returns the host read back from the route, and true
+// when the route was changed.
+func ensureRoute(sso *apiv1.SSO, serviceName string, appName string) ([]string, bool, error) {
+	host := ""
+	if sso.Spec.Domain != "" {
+		var err error
+		host, err = ingressHost(sso, serviceName)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "building the route host")
+		}
+	}
+	route, changed, err := ensureResource(proxyRoute(sso, serviceName, appName, host))
+	if err != nil {
+		return nil, false, err
+	}
+	host, ok, _ := unstructured.NestedString(route.Object, "spec", "host")
+	if !ok || host == "" {
+		return nil, false, errors.Errorf("no host found in route '%s'", route.GetName())
+	}
+	return []string{host}, changed, nil
+}
+
+// proxyRoute builds the OpenShift route of the proxy service, the TLS is terminated by the router
+func proxyRoute(sso *apiv1.SSO, serviceName string, appName string, host string) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"to": map[string]interface{}{
+			"kind": "Service",
+			"name": serviceName,
+		},
+		"port": map[string]interface{}{
+			"targetPort": portName,
+		},
+		"tls": map[string]interface{}{
+			"termination":                   "edge",
+			"insecureEdgeTerminationPolicy": "Redirect",
+		},
+	}
+	if host != "" {
+		spec["host"] = host
+	}
+
+	route := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": spec,
+		},
+	}
+	route.SetAPIVersion(routeAPIVersion)
+	route.SetKind(routeKind)
+	route.SetName(sso.GetName())
+	route.SetNamespace(sso.GetNamespace())
+	route.SetLabels(labels(sso, appName))
+	route.SetOwnerReferences([]metav1.OwnerReference{ownerRef(sso)})
+	return route
+}
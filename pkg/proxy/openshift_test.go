@@ -0,0 +1,32 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestProxyRoute(t *testing.T) {
+	sso := testSSO()
+
+	route := proxyRoute(sso, "sso-golang-http", "golang-http", "sso-golang-http.jx-staging.example.com")
+
+	assert.Equal(t, "route.openshift.io/v1", route.GetAPIVersion())
+	assert.Equal(t, "sso-golang-http", route.GetName())
+	host, _, _ := unstructured.NestedString(route.Object, "spec", "host")
+	assert.Equal(t, "sso-golang-http.jx-staging.example.com", host)
+	service, _, _ := unstructured.NestedString(route.Object, "spec", "to", "name")
+	assert.Equal(t, "sso-golang-http", service)
+	termination, _, _ := unstructured.NestedString(route.Object, "spec", "tls", "termination")
+	assert.Equal(t, "edge", termination)
+}
+
+func TestProxyRouteGeneratedHost(t *testing.T) {
+	sso := testSSO()
+
+	route := proxyRoute(sso, "sso-golang-http", "golang-http", "")
+
+	_, found, _ := unstructured.NestedString(route.Object, "spec", "host")
+	assert.False(t, found, "the host should be left to OpenShift")
+}
@@ -1,52 +1,53 @@
 package proxy
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
-	"time"
 
-	"github.com/dexidp/dex/api"
 	apiv1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/idp"
 	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
-	"github.com/operator-framework/operator-sdk/pkg/sdk"
 	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	k8slabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
-	configPath          = "/config/oauth2_proxy.cfg"
-	configVolumeName    = "proxy-config"
-	configSecretName    = "proxy-secret" // #nosec
-	secretVersionEnv    = "SECRET_VERSION"
-	portName            = "proxy-port"
-	port                = 4180
-	healthPath          = "/ping"
-	replicas            = 1
-	publicPort          = 80
-	cookieSecretLen     = 32
-	fakeURL             = "https://fake-oauth2-proxy"
-	createTimeout       = time.Duration(60 * time.Second)
-	createIntervalCheck = time.Duration(10 * time.Second)
-	readyTimeout        = time.Duration(5 * time.Minute)
-	appLabel            = "app"
-	releaseLabel        = "release"
-
-	exposeAnnotation         = "fabric8.io/expose"
-	exposeIngressAnnotation  = "fabric8.io/ingress.annotations"
-	ingressNameAnnotation    = "fabric8.io/ingress.name"
-	ingressClassAnnotations  = "kubernetes.io/ingress.class"
-	oldCertManagerAnnotation = "certmanager.k8s.io/issuer"
-	certManagerAnnotation    = "cert-manager.io/issuer"
-	ingressClass             = "nginx"
+	configPath       = "/config/oauth2_proxy.cfg"
+	emailsPath       = "/config/authenticated-emails.txt"
+	configVolumeName = "proxy-config"
+	configSecretName = "proxy-secret" // #nosec
+	secretVersionEnv = "SECRET_VERSION"
+	portName         = "proxy-port"
+	port             = 4180
+	healthPath       = "/ping"
+	replicas         = 1
+	publicPort       = 80
+	cookieSecretLen  = 32
+	clientSecretLen  = 32
+	fakeURL          = "https://fake-oauth2-proxy"
+	appLabel         = "app"
+	releaseLabel     = "release"
+
+	exposeAnnotation           = "fabric8.io/expose"
+	exposeIngressAnnotation    = "fabric8.io/ingress.annotations"
+	ingressNameAnnotation      = "fabric8.io/ingress.name"
+	ingressClassAnnotations    = "kubernetes.io/ingress.class"
+	oldCertManagerAnnotation   = "certmanager.k8s.io/issuer"
+	certManagerAnnotation      = "cert-manager.io/issuer"
+	oldClusterIssuerAnnotation = "certmanager.k8s.io/cluster-issuer"
+	clusterIssuerAnnotation    = "cert-manager.io/cluster-issuer"
+	ingressClass               = "nginx"
 )
 
 // Proxy keeps the k8s resources created for a proxy
@@ -70,13 +71,21 @@ func RedirectURL(URL string) string {
 // ConvertHostsToRedirectURLs converts a list of host to proxy redirect URLs
 func ConvertHostsToRedirectURLs(hosts []string, sso *apiv1.SSO) []string {
 	redirectURLs := []string{}
-	for _, host := range hosts {
-		redirectURL := RedirectURL(fmt.Sprintf("https://%s", host))
-		redirectURLs = append(redirectURLs, redirectURL)
+	for _, url := range ConvertHostsToURLs(hosts) {
+		redirectURLs = append(redirectURLs, RedirectURL(url))
 	}
 	return redirectURLs
 }
 
+// ConvertHostsToURLs converts a list of hosts to the public URLs of the proxy
+func ConvertHostsToURLs(hosts []string) []string {
+	urls := []string{}
+	for _, host := range hosts {
+		urls = append(urls, fmt.Sprintf("https://%s", host))
+	}
+	return urls
+}
+
 // buildName concatenates resourceName and suffix equally with a max length of 63 chars
 func buildName(resourceName string, suffix string) string {
 	name := resourceName
@@ -109,22 +118,67 @@ func labels(sso *apiv1.SSO, appName string) map[string]string {
 	return map[string]string{"app": appName, "sso": sso.GetName()}
 }
 
-func serviceAnnotations(sso *apiv1.SSO, appName string) map[string]string {
-	expIngressAnnotation := ingressClassAnnotations + ": " + ingressClass
-	if len(sso.Spec.CertIssuerName) != 0 {
-		expIngressAnnotation += "\n" + oldCertManagerAnnotation + ": " + sso.Spec.CertIssuerName
-		expIngressAnnotation += "\n" + certManagerAnnotation + ": " + sso.Spec.CertIssuerName
+// serviceAnnotations builds the annotations which configure the ingress created by exposecontroller, the
+// ingress annotations are serialized as a YAML map since their values are free-form
+func serviceAnnotations(sso *apiv1.SSO, appName string) (map[string]string, error) {
+	expIngressAnnotations := map[string]string{
+		ingressClassAnnotations: getIngressClass(sso),
+	}
+	for _, annotation := range ingressAnnotations(sso) {
+		expIngressAnnotations[annotation.key] = annotation.value
+	}
+	expIngressAnnotation, err := yaml.Marshal(expIngressAnnotations)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling the ingress annotations to YAML")
 	}
 	return map[string]string{
 		exposeAnnotation:        "true",
 		ingressNameAnnotation:   appName,
-		exposeIngressAnnotation: expIngressAnnotation,
+		exposeIngressAnnotation: string(expIngressAnnotation),
+	}, nil
+}
+
+type annotation struct {
+	key   string
+	value string
+}
+
+// getIngressClass returns the class of the ingress controller which exposes the proxy
+func getIngressClass(sso *apiv1.SSO) string {
+	if sso.Spec.IngressClassName != "" {
+		return sso.Spec.IngressClassName
+	}
+	return ingressClass
+}
+
+// ingressAnnotations returns in a stable order the annotations of the proxy ingress, the cert-manager annotations
+// come first followed by the custom annotations sorted by key
+func ingressAnnotations(sso *apiv1.SSO) []annotation {
+	annotations := []annotation{}
+	if len(sso.Spec.CertIssuerName) != 0 {
+		annotations = append(annotations,
+			annotation{key: oldCertManagerAnnotation, value: sso.Spec.CertIssuerName},
+			annotation{key: certManagerAnnotation, value: sso.Spec.CertIssuerName})
+	}
+	if len(sso.Spec.CertClusterIssuerName) != 0 {
+		annotations = append(annotations,
+			annotation{key: oldClusterIssuerAnnotation, value: sso.Spec.CertClusterIssuerName},
+			annotation{key: clusterIssuerAnnotation, value: sso.Spec.CertClusterIssuerName})
+	}
+	keys := []string{}
+	for key := range sso.Spec.IngressAnnotations {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		annotations = append(annotations, annotation{key: key, value: sso.Spec.IngressAnnotations[key]})
+	}
+	return annotations
 }
 
-// Deploy deploys the oauth2 proxy
-func Deploy(sso *apiv1.SSO, oidcClient *api.Client, cookieSecret string) (*Proxy, error) {
-	appName, err := getAppName(sso.Spec.UpstreamService, sso.GetNamespace())
+// Deploy deploys the oauth2 proxy, a NotReadyError is returned until its pods are running
+func Deploy(sso *apiv1.SSO, oidcClient *idp.Client, cookieSecret string) (*Proxy, error) {
+	appName, err := getAppName(Upstreams(sso)[0].Service, sso.GetNamespace())
 	if err != nil {
 		return nil, errors.Wrap(err, "gettting the app name from upstream service labels")
 	}
@@ -132,14 +186,51 @@ func Deploy(sso *apiv1.SSO, oidcClient *api.Client, cookieSecret string) (*Proxy
 	if err != nil {
 		return nil, errors.Wrap(err, "creating oauth2_proxy config")
 	}
+	c, err := kubernetes.GetClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting controller-runtime client")
+	}
 	secret.SetOwnerReferences(append(secret.GetOwnerReferences(), ownerRef(sso)))
-	err = sdk.Create(secret)
+	err = c.Create(context.TODO(), secret)
 	if err != nil && !apierrors.IsAlreadyExists(err) {
 		return nil, errors.Wrap(err, "creating oauth2_proxy secret")
 	}
 
+	d := proxyDeployment(sso, appName, computeSecretVersion(secret))
+	d.SetOwnerReferences(append(d.GetOwnerReferences(), ownerRef(sso)))
+
+	err = c.Create(context.TODO(), d)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, errors.Wrap(err, "creating oauth2_proxy deployment")
+	}
+
+	svc, err := proxyService(sso, appName)
+	if err != nil {
+		return nil, errors.Wrap(err, "building oauth2_proxy service")
+	}
+	svc.SetOwnerReferences(append(svc.GetOwnerReferences(), ownerRef(sso)))
+
+	err = c.Create(context.TODO(), svc)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, errors.Wrap(err, "creating oauth2_proxy service")
+	}
+
+	// The resources are created again by the next reconciliation which finds the pods running
+	err = checkPodsRunning(sso)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Proxy{
+		AppName:    appName,
+		Secret:     secret,
+		Deployment: d,
+		Service:    svc,
+	}, nil
+}
+
+func proxyDeployment(sso *apiv1.SSO, appName string, secretVersion string) *appsv1.Deployment {
 	ns := sso.GetNamespace()
-	secretVersion := computeSecretVersion(secret)
 	podTempl := v1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      buildName(sso.GetName(), ""),
@@ -162,15 +253,14 @@ func Deploy(sso *apiv1.SSO, oidcClient *api.Client, cookieSecret string) (*Proxy
 		},
 	}
 
-	deployment := buildName(sso.GetName(), "")
 	var replicas int32 = replicas
-	d := &appsv1.Deployment{
+	return &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Deployment",
 			APIVersion: "apps/v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      deployment,
+			Name:      buildName(sso.GetName(), ""),
 			Namespace: ns,
 			Labels:    labels(sso, appName),
 		},
@@ -187,25 +277,23 @@ func Deploy(sso *apiv1.SSO, oidcClient *api.Client, cookieSecret string) (*Proxy
 			},
 		},
 	}
+}
 
-	d.SetOwnerReferences(append(d.GetOwnerReferences(), ownerRef(sso)))
-
-	err = sdk.Create(d)
-	if err != nil && !apierrors.IsAlreadyExists(err) {
-		return nil, errors.Wrap(err, "creating oauth2_proxy deployment")
+func proxyService(sso *apiv1.SSO, appName string) (*v1.Service, error) {
+	annotations, err := serviceAnnotations(sso, appName)
+	if err != nil {
+		return nil, err
 	}
-
-	service := sso.GetName()
-	svc := &v1.Service{
+	return &v1.Service{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Service",
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        service,
-			Namespace:   ns,
+			Name:        sso.GetName(),
+			Namespace:   sso.GetNamespace(),
 			Labels:      labels(sso, appName),
-			Annotations: serviceAnnotations(sso, appName),
+			Annotations: annotations,
 		},
 		Spec: v1.ServiceSpec{
 			Ports: []v1.ServicePort{{
@@ -216,42 +304,11 @@ func Deploy(sso *apiv1.SSO, oidcClient *api.Client, cookieSecret string) (*Proxy
 			}},
 			Selector: labels(sso, appName),
 		},
-	}
-
-	svc.SetOwnerReferences(append(svc.GetOwnerReferences(), ownerRef(sso)))
-
-	err = sdk.Create(svc)
-	if err != nil && !apierrors.IsAlreadyExists(err) {
-		return nil, errors.Wrap(err, "creating oauth2_proxy service")
-	}
-
-	k8sClient, err := kubernetes.GetClientset()
-	if err != nil {
-		return nil, errors.Wrap(err, "getting k8s client")
-	}
-
-	exists := true
-	err = kubernetes.WaitForService(k8sClient, ns, service, exists, createIntervalCheck, createTimeout)
-	if err != nil {
-		return nil, errors.Wrap(err, "wait for service")
-	}
-
-	label := k8slabels.SelectorFromSet(k8slabels.Set(map[string]string{"sso": sso.GetName()}))
-	err = kubernetes.WaitForPodsWithLabelRunning(k8sClient, ns, label, readyTimeout)
-	if err != nil {
-		return nil, errors.Wrap(err, "waiting for SSO proxy")
-	}
-
-	return &Proxy{
-		AppName:    appName,
-		Secret:     secret,
-		Deployment: d,
-		Service:    svc,
 	}, nil
 }
 
-// Update updates the oauth2_proxy secret and deployment
-func Update(proxy *Proxy, sso *apiv1.SSO, client *api.Client, cookieSecret string) error {
+// Update updates the oauth2_proxy secret and deployment, the rollout of the deployment is checked with CheckRollout
+func Update(proxy *Proxy, sso *apiv1.SSO, client *idp.Client, cookieSecret string) error {
 	err := updateProxySecret(proxy.Secret, sso, client, cookieSecret)
 	if err != nil {
 		return errors.Wrap(err, "updating oauth2_proxy secret")
@@ -261,9 +318,13 @@ func Update(proxy *Proxy, sso *apiv1.SSO, client *api.Client, cookieSecret strin
 	if err != nil {
 		return errors.Wrap(err, "getting k8s client")
 	}
+	c, err := kubernetes.GetClient()
+	if err != nil {
+		return errors.Wrap(err, "getting controller-runtime client")
+	}
 
 	namespace := sso.GetNamespace()
-	deploymentList, err := k8sClient.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	deploymentList, err := k8sClient.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return errors.Wrap(err, "listing deployments")
 	}
@@ -275,22 +336,12 @@ func Update(proxy *Proxy, sso *apiv1.SSO, client *api.Client, cookieSecret strin
 			for _, container := range containers {
 				updateContainer(&container, secretVersion) // #nosec
 			}
-			deployment.TypeMeta = metav1.TypeMeta{
-				Kind:       "Deployment",
-				APIVersion: "apps/v1",
-			}
-			err = sdk.Update(&deployment) // #nosec
+			err = c.Update(context.TODO(), &deployment) // #nosec
 			if err != nil {
 				return errors.Wrap(err, "updating oauth2_proxy deployment")
 			}
 		}
 	}
-
-	label := k8slabels.SelectorFromSet(k8slabels.Set(map[string]string{"sso": sso.GetName()}))
-	err = kubernetes.WaitForPodsWithLabelRunning(k8sClient, sso.GetNamespace(), label, readyTimeout)
-	if err != nil {
-		return errors.Wrap(err, "waiting for SSO proxy")
-	}
 	return nil
 }
 
@@ -303,9 +354,14 @@ func updateContainer(container *v1.Container, secretVersion string) {
 }
 
 func computeSecretVersion(secret *v1.Secret) string {
+	keys := []string{}
+	for k := range secret.StringData {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 	secretData := ""
-	for k, v := range secret.StringData {
-		secretData += k + v
+	for _, k := range keys {
+		secretData += k + secret.StringData[k]
 	}
 	hash := sha256.Sum256([]byte(secretData))
 	secretVersion := base64.URLEncoding.EncodeToString(hash[:])
@@ -350,7 +406,7 @@ func proxyContainer(sso *apiv1.SSO, secretVersion string) v1.Container {
 			Value: secretVersion,
 		}},
 		LivenessProbe: &v1.Probe{
-			Handler: v1.Handler{
+			ProbeHandler: v1.ProbeHandler{
 				HTTPGet: &v1.HTTPGetAction{
 					Path:   healthPath,
 					Port:   intstr.FromInt(port),
@@ -363,7 +419,7 @@ func proxyContainer(sso *apiv1.SSO, secretVersion string) v1.Container {
 			FailureThreshold:    3,
 		},
 		ReadinessProbe: &v1.Probe{
-			Handler: v1.Handler{
+			ProbeHandler: v1.ProbeHandler{
 				HTTPGet: &v1.HTTPGetAction{
 					Path:   healthPath,
 					Port:   intstr.FromInt(port),
@@ -378,12 +434,21 @@ func proxyContainer(sso *apiv1.SSO, secretVersion string) v1.Container {
 	}
 }
 
-func proxyConfig(sso *apiv1.SSO, client *api.Client, cookieSecret string) (string, error) {
-	upstreamURL, err := getUpstreamURL(sso.Spec.UpstreamService, sso.Namespace)
-	if err != nil {
-		return "", errors.Wrap(err, "getting the upstream service URL")
+func proxyConfig(sso *apiv1.SSO, client *idp.Client, cookieSecret string) (string, error) {
+	upstreamURLs := []string{}
+	for _, upstream := range Upstreams(sso) {
+		upstreamURL, err := getUpstreamURL(upstream, sso.Namespace)
+		if err != nil {
+			return "", errors.Wrapf(err, "getting the URL of upstream service '%s'", upstream.Service)
+		}
+		if len(sso.Spec.Upstreams) == 0 {
+			// The upstream service keeps the URL rendered before the upstreams were routed by path, otherwise
+			// every proxy would be rolled out on upgrade
+			upstreamURL = strings.TrimSuffix(upstreamURL, "/")
+		}
+		upstreamURLs = append(upstreamURLs, upstreamURL)
 	}
-	redirectURLs := client.RedirectUris
+	redirectURLs := client.RedirectURIs
 	if len(redirectURLs) == 0 {
 		return "", errors.New("no redirect URL provided")
 	}
@@ -393,14 +458,16 @@ func proxyConfig(sso *apiv1.SSO, client *api.Client, cookieSecret string) (strin
 	}
 	c := &Config{
 		Port:          port,
-		ClientID:      client.GetId(),
-		ClientSecret:  client.GetSecret(),
+		ClientID:      client.OIDCClientID(),
+		ClientSecret:  client.Secret,
 		OIDCIssuerURL: sso.Spec.OIDCIssuerURL,
 		RedirectURL:   redirectURLs[0],
 		LoginURL:      fmt.Sprintf("%s/auth", issuerURL),
 		RedeemURL:     fmt.Sprintf("%s/token", issuerURL),
-		Upstream:      upstreamURL,
+		Upstreams:     upstreamURLs,
 		ForwardToken:  sso.Spec.ForwardToken,
+		EmailDomains:  emailDomains(sso),
+		AllowedGroups: sso.Spec.AllowedGroups,
 		Cookie: Cookie{
 			Name:     sso.Spec.CookieSpec.Name,
 			Secret:   cookieSecret,
@@ -412,6 +479,10 @@ func proxyConfig(sso *apiv1.SSO, client *api.Client, cookieSecret string) (strin
 		},
 	}
 
+	if len(sso.Spec.AllowedUsers) > 0 {
+		c.AuthenticatedEmailsFile = emailsPath
+	}
+
 	config, err := renderConfig(c)
 	if err != nil {
 		return "", errors.Wrap(err, "rendering oauth2_proxy config")
@@ -419,25 +490,56 @@ func proxyConfig(sso *apiv1.SSO, client *api.Client, cookieSecret string) (strin
 	return config, nil
 }
 
-func updateProxySecret(secret *v1.Secret, sso *apiv1.SSO, client *api.Client, cookieSecret string) error {
+// emailDomains returns the email domains allowed to authenticate, any domain is allowed unless
+// the access is restricted to some users
+func emailDomains(sso *apiv1.SSO) []string {
+	if len(sso.Spec.AllowedEmailDomains) > 0 {
+		return sso.Spec.AllowedEmailDomains
+	}
+	if len(sso.Spec.AllowedUsers) > 0 {
+		return nil
+	}
+	return []string{"*"}
+}
+
+// proxySecretData builds the files of the oauth2_proxy secret
+func proxySecretData(sso *apiv1.SSO, client *idp.Client, cookieSecret string) (map[string]string, error) {
 	config, err := proxyConfig(sso, client, cookieSecret)
 	if err != nil {
-		return errors.Wrap(err, "creating oauth2_proxy config")
+		return nil, errors.Wrap(err, "creating oauth2_proxy config")
+	}
+	data := map[string]string{
+		filepath.Base(configPath): config,
+	}
+	if len(sso.Spec.AllowedUsers) > 0 {
+		data[filepath.Base(emailsPath)] = strings.Join(sso.Spec.AllowedUsers, "\n") + "\n"
+	}
+	return data, nil
+}
+
+func updateProxySecret(secret *v1.Secret, sso *apiv1.SSO, client *idp.Client, cookieSecret string) error {
+	data, err := proxySecretData(sso, client, cookieSecret)
+	if err != nil {
+		return errors.Wrap(err, "creating oauth2_proxy secret data")
 	}
 
-	secret.StringData[filepath.Base(configPath)] = config
+	secret.StringData = data
 
-	err = sdk.Update(secret)
+	c, err := kubernetes.GetClient()
+	if err != nil {
+		return errors.Wrap(err, "getting controller-runtime client")
+	}
+	err = c.Update(context.TODO(), secret)
 	if err != nil {
 		return errors.Wrap(err, "updating oauth2_proxy secret")
 	}
 	return nil
 }
 
-func proxySecret(sso *apiv1.SSO, client *api.Client, cookieSecret string, labels map[string]string) (*v1.Secret, error) {
-	config, err := proxyConfig(sso, client, cookieSecret)
+func proxySecret(sso *apiv1.SSO, client *idp.Client, cookieSecret string, labels map[string]string) (*v1.Secret, error) {
+	data, err := proxySecretData(sso, client, cookieSecret)
 	if err != nil {
-		return nil, errors.Wrap(err, "creating oauth2_proxy config")
+		return nil, errors.Wrap(err, "creating oauth2_proxy secret data")
 	}
 	secret := &v1.Secret{
 		TypeMeta: metav1.TypeMeta{
@@ -449,10 +551,8 @@ func proxySecret(sso *apiv1.SSO, client *api.Client, cookieSecret string, labels
 			Namespace: sso.Namespace,
 			Labels:    labels,
 		},
-		StringData: map[string]string{
-			filepath.Base(configPath): config,
-		},
-		Type: v1.SecretTypeOpaque,
+		StringData: data,
+		Type:       v1.SecretTypeOpaque,
 	}
 	return secret, nil
 }
@@ -462,6 +562,11 @@ func GenerateCookieKey() (string, error) {
 	return generateSecret(cookieSecretLen)
 }
 
+// GenerateClientSecret generates a random secret for the OIDC client
+func GenerateClientSecret() (string, error) {
+	return generateSecret(clientSecretLen)
+}
+
 func generateSecret(size int) (string, error) {
 	const letters = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
 	bytes, err := generateRandomBytes(size)
@@ -483,23 +588,66 @@ func generateRandomBytes(len int) ([]byte, error) {
 	return b, nil
 }
 
-func getUpstreamURL(upstreamService string, namespace string) (string, error) {
+// Upstreams returns the upstreams protected by the SSO proxy, the upstream service is routed on the root path
+func Upstreams(sso *apiv1.SSO) []apiv1.Upstream {
+	if len(sso.Spec.Upstreams) > 0 {
+		return sso.Spec.Upstreams
+	}
+	return []apiv1.Upstream{{
+		Service: sso.Spec.UpstreamService,
+	}}
+}
+
+func getUpstreamURL(upstream apiv1.Upstream, namespace string) (string, error) {
 	kubeClient, err := kubernetes.GetClientset()
 	if err != nil {
 		return "", errors.Wrap(err, "creating k8s client")
 	}
 
-	serviceList, err := kubeClient.CoreV1().Services(namespace).List(metav1.ListOptions{})
+	serviceList, err := kubeClient.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return "", errors.Wrapf(err, "listing services in namespace '%s'", namespace)
 	}
 	for _, service := range serviceList.Items {
-		if service.GetName() == upstreamService {
-			port := service.Spec.Ports[0].Port
-			return fmt.Sprintf("http://%s:%d", service.Name, port), nil
+		if service.GetName() == upstream.Service {
+			port, err := findServicePort(service.Spec.Ports, upstream.Port)
+			if err != nil {
+				return "", errors.Wrapf(err, "service '%s'", service.GetName())
+			}
+			return upstreamURL(service.GetName(), port, upstream.Path), nil
 		}
 	}
-	return "", fmt.Errorf("no service '%s' found in namespace '%s'", upstreamService, namespace)
+	return "", fmt.Errorf("no service '%s' found in namespace '%s'", upstream.Service, namespace)
+}
+
+// findServicePort finds a service port by name or number, the first port is returned when no port is requested
+func findServicePort(ports []v1.ServicePort, port intstr.IntOrString) (int32, error) {
+	if len(ports) == 0 {
+		return 0, errors.New("no ports defined")
+	}
+	if (port.Type == intstr.Int && port.IntVal == 0) || (port.Type == intstr.String && port.StrVal == "") {
+		return ports[0].Port, nil
+	}
+	for _, p := range ports {
+		switch {
+		case port.Type == intstr.String && p.Name == port.StrVal:
+			return p.Port, nil
+		case port.Type == intstr.Int && p.Port == port.IntVal:
+			return p.Port, nil
+		}
+	}
+	return 0, fmt.Errorf("no port '%s' defined", port.String())
+}
+
+// upstreamURL builds the URL of an upstream service, oauth2_proxy routes the requests based on its path
+func upstreamURL(service string, port int32, path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	return fmt.Sprintf("http://%s:%d%s", service, port, path)
 }
 
 func getAppName(upstreamService string, namespace string) (string, error) {
@@ -507,7 +655,7 @@ func getAppName(upstreamService string, namespace string) (string, error) {
 	if err != nil {
 		return "", errors.Wrap(err, "creating k8s client")
 	}
-	serviceList, err := kubeClient.CoreV1().Services(namespace).List(metav1.ListOptions{})
+	serviceList, err := kubeClient.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return "", errors.Wrapf(err, "listing services in namespace '%s'", namespace)
 	}
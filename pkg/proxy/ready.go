@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"fmt"
+
+	apiv1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/pkg/errors"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+)
+
+// NotReadyError indicates that a resource of the proxy is not ready yet, the operation is resumed by a
+// later reconciliation instead of waiting for the resource
+type NotReadyError struct {
+	message string
+}
+
+func (e *NotReadyError) Error() string {
+	return e.message
+}
+
+func notReady(format string, args ...interface{}) error {
+	return &NotReadyError{message: fmt.Sprintf(format, args...)}
+}
+
+// IsNotReady checks if the cause of the error is a resource which is not ready yet
+func IsNotReady(err error) bool {
+	_, ok := errors.Cause(err).(*NotReadyError)
+	return ok
+}
+
+// CheckRollout returns a NotReadyError until the oauth2_proxy deployment of the SSO is rolled out and all its
+// pods are ready
+func CheckRollout(sso *apiv1.SSO) error {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+	ns := sso.GetNamespace()
+	name := buildName(sso.GetName(), "")
+	rolledOut, err := kubernetes.DeploymentRolledOut(k8sClient, ns, name)
+	if err != nil {
+		return errors.Wrapf(err, "checking the rollout of oauth2_proxy deployment '%s'", name)
+	}
+	if !rolledOut {
+		return notReady("oauth2_proxy deployment '%s' is rolling out", name)
+	}
+	return checkPodsRunning(sso)
+}
+
+// checkPodsRunning returns a NotReadyError until the pods of the oauth2_proxy are running
+func checkPodsRunning(sso *apiv1.SSO) error {
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+	label := k8slabels.SelectorFromSet(k8slabels.Set(map[string]string{"sso": sso.GetName()}))
+	running, err := kubernetes.PodsWithLabelRunning(k8sClient, sso.GetNamespace(), label)
+	if err != nil {
+		return errors.Wrap(err, "listing the oauth2_proxy pods")
+	}
+	if !running {
+		return notReady("oauth2_proxy pods of SSO '%s' are not running", sso.GetName())
+	}
+	return nil
+}
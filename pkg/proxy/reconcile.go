@@ -0,0 +1,234 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/idp"
+	"github.com/jenkins-x/sso-operator/pkg/kubernetes"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reconcile compares the oauth2_proxy resources of an initialized SSO with the desired state derived
+// from its spec, and updates in place the secret, deployment and service which drifted. It returns the public
+// hosts of the proxy when it is exposed by a resource owned by the SSO, and true when at least one resource
+// was changed.
+func Reconcile(sso *apiv1.SSO, cookieSecret string) ([]string, bool, error) {
+	appName, err := getAppName(Upstreams(sso)[0].Service, sso.GetNamespace())
+	if err != nil {
+		return nil, false, errors.Wrap(err, "gettting the app name from upstream service labels")
+	}
+
+	k8sClient, err := kubernetes.GetClientset()
+	if err != nil {
+		return nil, false, errors.Wrap(err, "getting k8s client")
+	}
+	c, err := kubernetes.GetClient()
+	if err != nil {
+		return nil, false, errors.Wrap(err, "getting controller-runtime client")
+	}
+	ns := sso.GetNamespace()
+	changed := false
+
+	// The OIDC client credentials are only known by the proxy config, read them back from the current secret
+	currentSecret, err := k8sClient.CoreV1().Secrets(ns).Get(context.TODO(), buildName(sso.GetName(), configSecretName), metav1.GetOptions{})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "getting oauth2_proxy secret")
+	}
+	configKey := filepath.Base(configPath)
+	client, err := parseClientConfig(string(currentSecret.Data[configKey]))
+	if err != nil {
+		return nil, false, errors.Wrap(err, "reading the OIDC client from oauth2_proxy config")
+	}
+	secret, err := proxySecret(sso, client, cookieSecret, labels(sso, appName))
+	if err != nil {
+		return nil, false, errors.Wrap(err, "creating oauth2_proxy config")
+	}
+	if !equalSecretData(currentSecret.Data, secret.StringData) {
+		logrus.Infof("Updating oauth2_proxy secret '%s'", currentSecret.GetName())
+		currentSecret.Data = nil
+		currentSecret.StringData = secret.StringData
+		err = c.Update(context.TODO(), currentSecret)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "updating oauth2_proxy secret")
+		}
+		changed = true
+	}
+
+	d := proxyDeployment(sso, appName, computeSecretVersion(secret))
+	currentDeployment, err := k8sClient.AppsV1().Deployments(ns).Get(context.TODO(), d.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "getting oauth2_proxy deployment")
+	}
+	template := ownedTemplate(d.Spec.Template, currentDeployment.Spec.Template)
+	if !equality.Semantic.DeepEqual(template, currentDeployment.Spec.Template) ||
+		!equality.Semantic.DeepEqual(d.Spec.Replicas, currentDeployment.Spec.Replicas) {
+		logrus.Infof("Updating oauth2_proxy deployment '%s'", currentDeployment.GetName())
+		currentDeployment.Spec.Template = template
+		currentDeployment.Spec.Replicas = d.Spec.Replicas
+		err = c.Update(context.TODO(), currentDeployment)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "updating oauth2_proxy deployment")
+		}
+		changed = true
+	}
+
+	svc, err := proxyService(sso, appName)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "building oauth2_proxy service")
+	}
+	currentService, err := k8sClient.CoreV1().Services(ns).Get(context.TODO(), svc.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "getting oauth2_proxy service")
+	}
+	annotations := ownedAnnotations(svc.GetAnnotations(), currentService.GetAnnotations())
+	if !equality.Semantic.DeepEqual(annotations, currentService.GetAnnotations()) ||
+		!equality.Semantic.DeepEqual(svc.Spec.Ports, currentService.Spec.Ports) {
+		logrus.Infof("Updating oauth2_proxy service '%s'", currentService.GetName())
+		currentService.SetAnnotations(annotations)
+		currentService.Spec.Ports = svc.Spec.Ports
+		err = c.Update(context.TODO(), currentService)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "updating oauth2_proxy service")
+		}
+		changed = true
+	}
+
+	if !sso.Spec.SkipExposeService && !IsExposeController(sso) {
+		hosts, updated, err := ensureExposure(sso, svc.GetName(), appName)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "reconciling oauth2_proxy exposure")
+		}
+		return hosts, changed || updated, nil
+	}
+
+	return nil, changed, nil
+}
+
+// ownedTemplate returns the current pod template of the proxy with the fields owned by the operator replaced by
+// the desired ones. The fields defaulted by the API server are kept, so a field cleared in the SSO spec is detected
+// when the templates are compared with DeepEqual.
+func ownedTemplate(desired v1.PodTemplateSpec, current v1.PodTemplateSpec) v1.PodTemplateSpec {
+	template := *current.DeepCopy()
+	template.Labels = desired.Labels
+	template.Spec.ImagePullSecrets = desired.Spec.ImagePullSecrets
+
+	template.Spec.Volumes = desired.Spec.Volumes
+	for i, volume := range template.Spec.Volumes {
+		currentVolume := findVolume(current.Spec.Volumes, volume.Name)
+		if volume.Secret != nil && volume.Secret.DefaultMode == nil && currentVolume != nil && currentVolume.Secret != nil {
+			secret := *volume.Secret
+			secret.DefaultMode = currentVolume.Secret.DefaultMode
+			template.Spec.Volumes[i].Secret = &secret
+		}
+	}
+
+	if len(desired.Spec.Containers) != len(current.Spec.Containers) {
+		template.Spec.Containers = desired.Spec.Containers
+		return template
+	}
+	for i, container := range desired.Spec.Containers {
+		c := &template.Spec.Containers[i]
+		if c.Name != container.Name {
+			template.Spec.Containers = desired.Spec.Containers
+			return template
+		}
+		c.Image = container.Image
+		c.ImagePullPolicy = container.ImagePullPolicy
+		c.Args = container.Args
+		c.Ports = container.Ports
+		c.Resources = container.Resources
+		c.VolumeMounts = container.VolumeMounts
+		c.Env = container.Env
+		c.LivenessProbe = ownedProbe(container.LivenessProbe, c.LivenessProbe)
+		c.ReadinessProbe = ownedProbe(container.ReadinessProbe, c.ReadinessProbe)
+	}
+	return template
+}
+
+// ownedProbe returns the desired probe with the success threshold defaulted by the API server
+func ownedProbe(desired *v1.Probe, current *v1.Probe) *v1.Probe {
+	if desired == nil {
+		return nil
+	}
+	probe := desired.DeepCopy()
+	if probe.SuccessThreshold == 0 && current != nil {
+		probe.SuccessThreshold = current.SuccessThreshold
+	}
+	return probe
+}
+
+func findVolume(volumes []v1.Volume, name string) *v1.Volume {
+	for i := range volumes {
+		if volumes[i].Name == name {
+			return &volumes[i]
+		}
+	}
+	return nil
+}
+
+// ownedAnnotations returns the current annotations of the proxy service with the annotations owned by the operator
+// replaced by the desired ones, an owned annotation which is not desired anymore is removed
+func ownedAnnotations(desired map[string]string, current map[string]string) map[string]string {
+	annotations := map[string]string{}
+	for k, v := range current {
+		annotations[k] = v
+	}
+	for _, k := range []string{exposeAnnotation, ingressNameAnnotation, exposeIngressAnnotation} {
+		delete(annotations, k)
+	}
+	for k, v := range desired {
+		annotations[k] = v
+	}
+	return annotations
+}
+
+// equalSecretData compares the data of an existing secret with the string data of a desired secret
+func equalSecretData(data map[string][]byte, stringData map[string]string) bool {
+	if len(data) != len(stringData) {
+		return false
+	}
+	for k, v := range stringData {
+		current, ok := data[k]
+		if !ok || string(current) != v {
+			return false
+		}
+	}
+	return true
+}
+
+// parseClientConfig reads the OIDC client settings back from a rendered oauth2_proxy config
+func parseClientConfig(config string) (*idp.Client, error) {
+	client := &idp.Client{}
+	scanner := bufio.NewScanner(strings.NewReader(config))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value, err := strconv.Unquote(strings.TrimSpace(parts[1]))
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(parts[0]) {
+		case "client_id":
+			client.ID = value
+		case "client_secret":
+			client.Secret = value
+		case "redirect_url":
+			client.RedirectURIs = []string{value}
+		}
+	}
+	if client.ID == "" || client.Secret == "" || len(client.RedirectURIs) == 0 {
+		return nil, errors.New("client id, secret or redirect URL missing from oauth2_proxy config")
+	}
+	return client, nil
+}
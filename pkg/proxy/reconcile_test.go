@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParseClientConfig(t *testing.T) {
+	config := &Config{
+		Port:          4180,
+		ClientID:      "123",
+		ClientSecret:  "test",
+		OIDCIssuerURL: "https://test-issuer",
+		RedirectURL:   "https://test-proxy/oauth2/callback",
+		Upstreams:     []string{"http://test-upstream"},
+	}
+	strConfig, err := renderConfig(config)
+	assert.NoError(t, err, "should render proxy config without error")
+
+	client, err := parseClientConfig(strConfig)
+
+	assert.NoError(t, err, "should parse the OIDC client from proxy config without error")
+	assert.Equal(t, "123", client.ID)
+	assert.Equal(t, "test", client.Secret)
+	assert.Equal(t, []string{"https://test-proxy/oauth2/callback"}, client.RedirectURIs)
+}
+
+func TestParseClientConfigMissingSecret(t *testing.T) {
+	_, err := parseClientConfig("client_id = \"123\"\nredirect_url = \"https://test-proxy/oauth2/callback\"\n")
+
+	assert.Error(t, err, "should fail when the client secret is missing")
+}
+
+// defaulted sets the fields of a pod template which the API server defaults
+func defaulted(template v1.PodTemplateSpec) v1.PodTemplateSpec {
+	template = *template.DeepCopy()
+	mode := int32(420)
+	template.Spec.Volumes[0].Secret.DefaultMode = &mode
+	template.Spec.RestartPolicy = v1.RestartPolicyAlways
+	template.Spec.DNSPolicy = v1.DNSClusterFirst
+	for i := range template.Spec.Containers {
+		c := &template.Spec.Containers[i]
+		c.TerminationMessagePath = v1.TerminationMessagePathDefault
+		c.LivenessProbe.SuccessThreshold = 1
+		c.ReadinessProbe.SuccessThreshold = 1
+	}
+	return template
+}
+
+func TestOwnedTemplateIgnoresServerDefaults(t *testing.T) {
+	sso := testSSO()
+	desired := proxyDeployment(sso, "golang-http", "version").Spec.Template
+	current := defaulted(desired)
+
+	template := ownedTemplate(desired, current)
+
+	assert.True(t, equality.Semantic.DeepEqual(template, current))
+}
+
+func TestOwnedTemplateClearsResources(t *testing.T) {
+	sso := testSSO()
+	sso.Spec.ProxyResources = v1.ResourceRequirements{
+		Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("128Mi")},
+	}
+	current := defaulted(proxyDeployment(sso, "golang-http", "version").Spec.Template)
+	sso.Spec.ProxyResources = v1.ResourceRequirements{}
+	desired := proxyDeployment(sso, "golang-http", "version").Spec.Template
+
+	template := ownedTemplate(desired, current)
+
+	assert.False(t, equality.Semantic.DeepEqual(template, current), "the cleared resources should be detected")
+	assert.Empty(t, template.Spec.Containers[0].Resources.Limits)
+	assert.Equal(t, v1.TerminationMessagePathDefault, template.Spec.Containers[0].TerminationMessagePath)
+}
+
+func TestOwnedAnnotations(t *testing.T) {
+	current := map[string]string{
+		exposeAnnotation:        "true",
+		exposeIngressAnnotation: "kubernetes.io/ingress.class: nginx\n",
+		"example.com/owner":     "team",
+	}
+	desired := map[string]string{
+		exposeAnnotation:      "true",
+		ingressNameAnnotation: "golang-http",
+	}
+
+	annotations := ownedAnnotations(desired, current)
+
+	assert.Equal(t, map[string]string{
+		exposeAnnotation:      "true",
+		ingressNameAnnotation: "golang-http",
+		"example.com/owner":   "team",
+	}, annotations)
+	assert.True(t, equality.Semantic.DeepEqual(ownedAnnotations(nil, nil), map[string]string(nil)))
+}
@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	apiv1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	httpRouteAPIVersion = "gateway.networking.k8s.io/v1"
+	httpRouteKind       = "HTTPRoute"
+)
+
+// ensureHTTPRoute creates the HTTPRoute of the proxy service or updates it when it differs from the SSO spec. It
+// returns the hostnames read back from the route, and true when the route was changed.
+func ensureHTTPRoute(sso *apiv1.SSO, serviceName string, appName string) ([]string, bool, error) {
+	if sso.Spec.Gateway == nil || sso.Spec.Gateway.Name == "" {
+		return nil, false, errors.New("a gateway is required to expose the SSO proxy with a HTTPRoute")
+	}
+	host, err := ingressHost(sso, serviceName)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "building the route hostname")
+	}
+	route, changed, err := ensureResource(proxyHTTPRoute(sso, serviceName, appName, host))
+	if err != nil {
+		return nil, false, err
+	}
+	hosts, ok, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	if !ok || len(hosts) == 0 {
+		return nil, false, errors.Errorf("no hostname found in HTTPRoute '%s'", route.GetName())
+	}
+	return hosts, changed, nil
+}
+
+// proxyHTTPRoute builds the HTTPRoute which attaches the proxy service to the gateway of the SSO
+func proxyHTTPRoute(sso *apiv1.SSO, serviceName string, appName string, host string) *unstructured.Unstructured {
+	parentRef := map[string]interface{}{
+		"name": sso.Spec.Gateway.Name,
+	}
+	if sso.Spec.Gateway.Namespace != "" {
+		parentRef["namespace"] = sso.Spec.Gateway.Namespace
+	}
+	if sso.Spec.Gateway.SectionName != "" {
+		parentRef["sectionName"] = sso.Spec.Gateway.SectionName
+	}
+
+	route := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"parentRefs": []interface{}{parentRef},
+				"hostnames":  []interface{}{host},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"matches": []interface{}{
+							map[string]interface{}{
+								"path": map[string]interface{}{
+									"type":  "PathPrefix",
+									"value": "/",
+								},
+							},
+						},
+						"backendRefs": []interface{}{
+							map[string]interface{}{
+								"name": serviceName,
+								"port": int64(publicPort),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	route.SetAPIVersion(httpRouteAPIVersion)
+	route.SetKind(httpRouteKind)
+	route.SetName(sso.GetName())
+	route.SetNamespace(sso.GetNamespace())
+	route.SetLabels(labels(sso, appName))
+	route.SetOwnerReferences([]metav1.OwnerReference{ownerRef(sso)})
+	return route
+}
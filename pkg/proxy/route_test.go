@@ -0,0 +1,43 @@
+package proxy
+
+import (
+	"testing"
+
+	apiv1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestProxyHTTPRoute(t *testing.T) {
+	sso := testSSO()
+	sso.Spec.Exposer = apiv1.ExposerHTTPRoute
+	sso.Spec.Gateway = &apiv1.GatewayReference{
+		Name:        "public",
+		Namespace:   "gateways",
+		SectionName: "https",
+	}
+
+	route := proxyHTTPRoute(sso, "sso-golang-http", "golang-http", "sso-golang-http.jx-staging.example.com")
+
+	assert.Equal(t, "gateway.networking.k8s.io/v1", route.GetAPIVersion())
+	assert.Equal(t, "HTTPRoute", route.GetKind())
+	assert.Equal(t, "sso-golang-http", route.GetName())
+	assert.Equal(t, sso.GetUID(), route.GetOwnerReferences()[0].UID)
+
+	hosts, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	assert.Equal(t, []string{"sso-golang-http.jx-staging.example.com"}, hosts)
+	parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	assert.Equal(t, map[string]interface{}{"name": "public", "namespace": "gateways", "sectionName": "https"}, parentRefs[0])
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	backendRefs, _, _ := unstructured.NestedSlice(rules[0].(map[string]interface{}), "backendRefs")
+	assert.Equal(t, map[string]interface{}{"name": "sso-golang-http", "port": int64(publicPort)}, backendRefs[0])
+}
+
+func TestEnsureHTTPRouteRequiresGateway(t *testing.T) {
+	sso := testSSO()
+	sso.Spec.Exposer = apiv1.ExposerHTTPRoute
+
+	_, _, err := ensureHTTPRoute(sso, "sso-golang-http", "golang-http")
+
+	assert.Error(t, err)
+}
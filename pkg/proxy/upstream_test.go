@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"testing"
+
+	apiv1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestFindServicePort(t *testing.T) {
+	ports := []v1.ServicePort{
+		{Name: "http", Port: 80},
+		{Name: "grpc", Port: 9090},
+	}
+
+	port, err := findServicePort(ports, intstr.IntOrString{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(80), port, "should default to the first port")
+
+	port, err = findServicePort(ports, intstr.FromString("grpc"))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(9090), port, "should find the port by name")
+
+	port, err = findServicePort(ports, intstr.FromInt(9090))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(9090), port, "should find the port by number")
+
+	_, err = findServicePort(ports, intstr.FromString("metrics"))
+	assert.Error(t, err, "should fail for an unknown port")
+
+	_, err = findServicePort(nil, intstr.IntOrString{})
+	assert.Error(t, err, "should fail for a service without ports")
+}
+
+func TestUpstreamURL(t *testing.T) {
+	assert.Equal(t, "http://ui:80/", upstreamURL("ui", 80, ""))
+	assert.Equal(t, "http://api:8080/api/", upstreamURL("api", 8080, "/api"))
+	assert.Equal(t, "http://api:8080/api/v1/", upstreamURL("api", 8080, "api/v1/"))
+}
+
+func TestUpstreamsDefaultsToUpstreamService(t *testing.T) {
+	sso := &apiv1.SSO{
+		Spec: apiv1.SSOSpec{
+			UpstreamService: "golang-http",
+		},
+	}
+
+	upstreams := Upstreams(sso)
+
+	assert.Equal(t, []apiv1.Upstream{{Service: "golang-http"}}, upstreams)
+}
+
+func TestEmailDomains(t *testing.T) {
+	sso := &apiv1.SSO{}
+	assert.Equal(t, []string{"*"}, emailDomains(sso))
+
+	sso.Spec.AllowedUsers = []string{"jane@example.com"}
+	assert.Empty(t, emailDomains(sso))
+
+	sso.Spec.AllowedEmailDomains = []string{"example.com"}
+	assert.Equal(t, []string{"example.com"}, emailDomains(sso))
+}
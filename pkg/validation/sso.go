@@ -0,0 +1,260 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ServiceGetter gets a service by namespace and name
+type ServiceGetter func(namespace string, name string) (*corev1.Service, error)
+
+// minAllowedGroupsVersion is the first major version of oauth2_proxy which restricts the access by OIDC groups
+const minAllowedGroupsVersion = 7
+
+var exposers = []string{
+	"",
+	string(v1.ExposerExposeController),
+	string(v1.ExposerIngress),
+	string(v1.ExposerHTTPRoute),
+	string(v1.ExposerRoute),
+}
+
+// ValidateSSO checks that the spec of the SSO can be used to configure dex and the oauth2_proxy. The upstream
+// services are only checked when a service getter is provided.
+func ValidateSSO(sso *v1.SSO, getService ServiceGetter) field.ErrorList {
+	allErrs := field.ErrorList{}
+	spec := sso.Spec
+	specPath := field.NewPath("spec")
+
+	allErrs = append(allErrs, validateIssuerURL(spec.OIDCIssuerURL, specPath.Child("oidcIssuerUrl"))...)
+
+	if spec.ProxyImage == "" {
+		allErrs = append(allErrs, field.Required(specPath.Child("proxyImage"), "the oauth2_proxy image is required"))
+	}
+	if spec.ProxyImageTag == "" {
+		allErrs = append(allErrs, field.Required(specPath.Child("proxyImageTag"), "the oauth2_proxy image tag is required"))
+	}
+
+	allErrs = append(allErrs, validateUpstreams(sso, specPath, getService)...)
+	allErrs = append(allErrs, validateCookie(spec.CookieSpec, specPath.Child("cookieSpec"))...)
+	allErrs = append(allErrs, validateExposer(spec, specPath)...)
+
+	for i, domain := range spec.AllowedEmailDomains {
+		if strings.TrimSpace(domain) == "" {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("allowedEmailDomains").Index(i), domain, "must not be empty"))
+		}
+	}
+	for i, user := range spec.AllowedUsers {
+		if !strings.Contains(user, "@") {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("allowedUsers").Index(i), user, "must be an email address"))
+		}
+	}
+	allErrs = append(allErrs, validateAllowedGroups(spec, specPath)...)
+	// The allow-lists are written to the oauth2_proxy config and to the file of authenticated emails
+	allErrs = append(allErrs, validateNoControlCharacters(spec.AllowedEmailDomains, specPath.Child("allowedEmailDomains"))...)
+	allErrs = append(allErrs, validateNoControlCharacters(spec.AllowedGroups, specPath.Child("allowedGroups"))...)
+	allErrs = append(allErrs, validateNoControlCharacters(spec.AllowedUsers, specPath.Child("allowedUsers"))...)
+	return allErrs
+}
+
+// validateAllowedGroups rejects a group restriction which the oauth2_proxy would ignore, the proxy only
+// supports allowed_groups for the OIDC provider since v7.0.0
+func validateAllowedGroups(spec v1.SSOSpec, specPath *field.Path) field.ErrorList {
+	if len(spec.AllowedGroups) == 0 || spec.ProxyImageTag == "" {
+		return nil
+	}
+	major, ok := majorVersion(spec.ProxyImageTag)
+	if !ok || major < minAllowedGroupsVersion {
+		return field.ErrorList{field.Invalid(specPath.Child("allowedGroups"), spec.AllowedGroups,
+			fmt.Sprintf("requires a proxy image tag v%d.0.0 or newer, the proxy image tag is '%s'",
+				minAllowedGroupsVersion, spec.ProxyImageTag))}
+	}
+	return nil
+}
+
+// majorVersion parses the major version of an image tag such as v7.1.3 or 7.1.3-alpine
+func majorVersion(tag string) (int, bool) {
+	version := strings.TrimPrefix(tag, "v")
+	end := strings.IndexFunc(version, func(r rune) bool { return !unicode.IsDigit(r) })
+	if end == 0 || (end > 0 && version[end] != '.') {
+		return 0, false
+	}
+	if end > 0 {
+		version = version[:end]
+	}
+	major, err := strconv.Atoi(version)
+	if err != nil {
+		return 0, false
+	}
+	return major, true
+}
+
+func validateNoControlCharacters(values []string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for i, value := range values {
+		if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), value, "must not contain control characters"))
+		}
+	}
+	return allErrs
+}
+
+func validateIssuerURL(issuerURL string, fldPath *field.Path) field.ErrorList {
+	if issuerURL == "" {
+		return field.ErrorList{field.Required(fldPath, "the URL of the dex issuer is required")}
+	}
+	u, err := url.Parse(issuerURL)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fldPath, issuerURL, err.Error())}
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return field.ErrorList{field.Invalid(fldPath, issuerURL, "must be an absolute https URL")}
+	}
+	return nil
+}
+
+func validateUpstreams(sso *v1.SSO, specPath *field.Path, getService ServiceGetter) field.ErrorList {
+	allErrs := field.ErrorList{}
+	spec := sso.Spec
+	if len(spec.Upstreams) == 0 {
+		if spec.UpstreamService == "" {
+			return field.ErrorList{field.Required(specPath.Child("upstreamService"), "an upstream service or a list of upstreams is required")}
+		}
+		return validateService(sso.GetNamespace(), spec.UpstreamService, specPath.Child("upstreamService"), getService)
+	}
+
+	paths := map[string]bool{}
+	for i, upstream := range spec.Upstreams {
+		upstreamPath := specPath.Child("upstreams").Index(i)
+		if upstream.Service == "" {
+			allErrs = append(allErrs, field.Required(upstreamPath.Child("service"), "the upstream service is required"))
+		} else {
+			allErrs = append(allErrs, validateService(sso.GetNamespace(), upstream.Service, upstreamPath.Child("service"), getService)...)
+		}
+		allErrs = append(allErrs, validateUpstreamPort(upstream.Port, upstreamPath.Child("port"))...)
+		allErrs = append(allErrs, validateUpstreamPath(upstream.Path, upstreamPath.Child("path"))...)
+		path := "/" + strings.Trim(upstream.Path, "/")
+		if paths[path] {
+			allErrs = append(allErrs, field.Duplicate(upstreamPath.Child("path"), upstream.Path))
+		}
+		paths[path] = true
+	}
+	return allErrs
+}
+
+// validateUpstreamPort checks the port of an upstream, the zero value selects the first port of the service
+func validateUpstreamPort(port intstr.IntOrString, fldPath *field.Path) field.ErrorList {
+	var msgs []string
+	switch {
+	case port.Type == intstr.Int && port.IntVal != 0:
+		msgs = utilvalidation.IsValidPortNum(int(port.IntVal))
+	case port.Type == intstr.String && port.StrVal != "":
+		msgs = utilvalidation.IsValidPortName(port.StrVal)
+	}
+	allErrs := field.ErrorList{}
+	for _, msg := range msgs {
+		allErrs = append(allErrs, field.Invalid(fldPath, port.String(), msg))
+	}
+	return allErrs
+}
+
+// validateUpstreamPath checks that the path of an upstream is a clean URL path, since it is written to the
+// upstream URL in the oauth2_proxy config. A clean path is left unchanged by url.URL which escapes the quotes
+// and the spaces, and drops the scheme, the host, the query and the fragment.
+func validateUpstreamPath(path string, fldPath *field.Path) field.ErrorList {
+	u, err := url.Parse(path)
+	if err != nil || (&url.URL{Path: u.Path}).String() != path {
+		return field.ErrorList{field.Invalid(fldPath, path, "must be a URL path without quotes, spaces, query or fragment")}
+	}
+	return nil
+}
+
+func validateService(namespace string, name string, fldPath *field.Path, getService ServiceGetter) field.ErrorList {
+	if getService == nil {
+		return nil
+	}
+	_, err := getService(namespace, name)
+	if apierrors.IsNotFound(err) {
+		return field.ErrorList{field.NotFound(fldPath, name)}
+	}
+	if err != nil {
+		return field.ErrorList{field.InternalError(fldPath, err)}
+	}
+	return nil
+}
+
+func validateCookie(cookie v1.CookieSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	expire, expireErrs := validateDuration(cookie.Expire, fldPath.Child("expire"))
+	allErrs = append(allErrs, expireErrs...)
+	refresh, refreshErrs := validateDuration(cookie.Refresh, fldPath.Child("refresh"))
+	allErrs = append(allErrs, refreshErrs...)
+	if expire > 0 && refresh >= expire {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("refresh"), cookie.Refresh, "must be shorter than the cookie expiration"))
+	}
+	_, rotationErrs := validateDuration(cookie.RotationInterval, fldPath.Child("rotationInterval"))
+	return append(allErrs, rotationErrs...)
+}
+
+func validateDuration(value string, fldPath *field.Path) (time.Duration, field.ErrorList) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, field.ErrorList{field.Invalid(fldPath, value, "must be a duration such as 168h or 30m")}
+	}
+	if d < 0 {
+		return 0, field.ErrorList{field.Invalid(fldPath, value, "must not be negative")}
+	}
+	return d, nil
+}
+
+func validateExposer(spec v1.SSOSpec, specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	exposer := string(spec.Exposer)
+	if !contains(exposers, exposer) {
+		return field.ErrorList{field.NotSupported(specPath.Child("exposer"), exposer, exposers[1:])}
+	}
+	if spec.SkipExposeService {
+		return nil
+	}
+
+	if spec.URLTemplate != "" {
+		_, err := template.New("host").Parse(spec.URLTemplate)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("urlTemplate"), spec.URLTemplate, err.Error()))
+		}
+	}
+	switch spec.Exposer {
+	case v1.ExposerIngress, v1.ExposerHTTPRoute:
+		if spec.Domain == "" {
+			allErrs = append(allErrs, field.Required(specPath.Child("domain"), "the domain is required to build the host of the proxy"))
+		}
+	}
+	if spec.Exposer == v1.ExposerHTTPRoute && (spec.Gateway == nil || spec.Gateway.Name == "") {
+		allErrs = append(allErrs, field.Required(specPath.Child("gateway", "name"), "a gateway is required by the httproute exposer"))
+	}
+	return allErrs
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
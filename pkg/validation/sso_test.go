@@ -0,0 +1,154 @@
+package validation
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func validSSO() *v1.SSO {
+	return &v1.SSO{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sso-golang-http",
+			Namespace: "jx-staging",
+		},
+		Spec: v1.SSOSpec{
+			OIDCIssuerURL:   "https://dex.jx-staging.example.com",
+			UpstreamService: "golang-http",
+			Domain:          "example.com",
+			ProxyImage:      "quay.io/pusher/oauth2_proxy",
+			ProxyImageTag:   "v3.2.0",
+			CookieSpec: v1.CookieSpec{
+				Expire:  "168h",
+				Refresh: "60m",
+			},
+		},
+	}
+}
+
+func getService(namespace string, name string) (*corev1.Service, error) {
+	if name == "golang-http" {
+		return &corev1.Service{}, nil
+	}
+	return nil, apierrors.NewNotFound(corev1.Resource("services"), name)
+}
+
+func TestValidateSSO(t *testing.T) {
+	tests := map[string]struct {
+		change func(sso *v1.SSO)
+		fields []string
+	}{
+		"valid": {
+			change: func(sso *v1.SSO) {},
+		},
+		"issuer without https": {
+			change: func(sso *v1.SSO) { sso.Spec.OIDCIssuerURL = "http://dex.jx-staging.example.com" },
+			fields: []string{"spec.oidcIssuerUrl"},
+		},
+		"missing proxy image": {
+			change: func(sso *v1.SSO) { sso.Spec.ProxyImage = "" },
+			fields: []string{"spec.proxyImage"},
+		},
+		"unparseable cookie expiration": {
+			change: func(sso *v1.SSO) { sso.Spec.CookieSpec.Expire = "one week" },
+			fields: []string{"spec.cookieSpec.expire"},
+		},
+		"cookie refresh after expiration": {
+			change: func(sso *v1.SSO) { sso.Spec.CookieSpec.Refresh = "200h" },
+			fields: []string{"spec.cookieSpec.refresh"},
+		},
+		"nonexistent upstream service": {
+			change: func(sso *v1.SSO) { sso.Spec.UpstreamService = "missing" },
+			fields: []string{"spec.upstreamService"},
+		},
+		"duplicated upstream paths": {
+			change: func(sso *v1.SSO) {
+				sso.Spec.Upstreams = []v1.Upstream{{Service: "golang-http", Path: "/api"}, {Service: "golang-http", Path: "/api/"}}
+			},
+			fields: []string{"spec.upstreams[1].path"},
+		},
+		"upstream path which is not a clean URL path": {
+			change: func(sso *v1.SSO) {
+				sso.Spec.Upstreams = []v1.Upstream{
+					{Service: "golang-http", Path: "/api\"\nskip_auth_regex = [\".*"},
+					{Service: "golang-http", Path: "/ui?debug=true"},
+					{Service: "golang-http", Path: "/docs v1"},
+					{Service: "golang-http", Path: "/static/v1%20"},
+					{Service: "golang-http", Path: "docs/v2/"},
+				}
+			},
+			fields: []string{"spec.upstreams[0].path", "spec.upstreams[1].path", "spec.upstreams[2].path"},
+		},
+		"upstream port out of range": {
+			change: func(sso *v1.SSO) {
+				sso.Spec.Upstreams = []v1.Upstream{
+					{Service: "golang-http", Path: "/", Port: intstr.FromInt(70000)},
+					{Service: "golang-http", Path: "/api", Port: intstr.FromString("not a port name")},
+					{Service: "golang-http", Path: "/ui", Port: intstr.FromString("http")},
+				}
+			},
+			fields: []string{"spec.upstreams[0].port", "spec.upstreams[1].port"},
+		},
+		"unknown exposer": {
+			change: func(sso *v1.SSO) { sso.Spec.Exposer = "loadbalancer" },
+			fields: []string{"spec.exposer"},
+		},
+		"httproute without gateway": {
+			change: func(sso *v1.SSO) { sso.Spec.Exposer = v1.ExposerHTTPRoute },
+			fields: []string{"spec.gateway.name"},
+		},
+		"allowed user without email": {
+			change: func(sso *v1.SSO) { sso.Spec.AllowedUsers = []string{"jane"} },
+			fields: []string{"spec.allowedUsers[0]"},
+		},
+		"allowed groups with a proxy which ignores them": {
+			change: func(sso *v1.SSO) { sso.Spec.AllowedGroups = []string{"admins"} },
+			fields: []string{"spec.allowedGroups"},
+		},
+		"allowed groups with a proxy of unknown version": {
+			change: func(sso *v1.SSO) {
+				sso.Spec.AllowedGroups = []string{"admins"}
+				sso.Spec.ProxyImageTag = "latest"
+			},
+			fields: []string{"spec.allowedGroups"},
+		},
+		"allowed groups with a proxy which supports them": {
+			change: func(sso *v1.SSO) {
+				sso.Spec.AllowedGroups = []string{"admins"}
+				sso.Spec.ProxyImageTag = "v7.4.0-alpine"
+			},
+		},
+		"allow-lists with control characters": {
+			change: func(sso *v1.SSO) {
+				sso.Spec.AllowedEmailDomains = []string{"example.com", "example.org\"]\nskip_auth_regex = [\".*"}
+				sso.Spec.AllowedUsers = []string{"jane@example.com\njohn@example.org"}
+				sso.Spec.AllowedGroups = []string{"admins\x00"}
+				sso.Spec.ProxyImageTag = "v7.4.0"
+			},
+			fields: []string{"spec.allowedEmailDomains[1]", "spec.allowedGroups[0]", "spec.allowedUsers[0]"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sso := validSSO()
+			test.change(sso)
+
+			errs := ValidateSSO(sso, getService)
+
+			fields := []string{}
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			if test.fields == nil {
+				assert.Empty(t, fields)
+			} else {
+				assert.Equal(t, test.fields, fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/defaults"
+	"github.com/jenkins-x/sso-operator/pkg/validation"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// ValidatePath is the path under which the SSO resources are validated
+	ValidatePath = "/validate-sso"
+	// MutatePath is the path under which the defaults are applied to the SSO resources
+	MutatePath = "/mutate-sso"
+
+	maxRequestSize  = 1 << 20
+	shutdownTimeout = 5 * time.Second
+)
+
+// Server serves over TLS the validating and mutating admission webhooks of the operator
+type Server struct {
+	addr       string
+	certFile   string
+	keyFile    string
+	getService validation.ServiceGetter
+	defaults   *defaults.Defaults
+}
+
+// NewServer creates a new webhook server listening on the given address
+func NewServer(addr string, certFile string, keyFile string, getService validation.ServiceGetter,
+	defaults *defaults.Defaults) *Server {
+	return &Server{
+		addr:       addr,
+		certFile:   certFile,
+		keyFile:    keyFile,
+		getService: getService,
+		defaults:   defaults,
+	}
+}
+
+// Run serves the webhooks until the context is cancelled
+func (s *Server) Run(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ValidatePath, s.serveValidate)
+	mux.HandleFunc(MutatePath, s.serveMutate)
+	server := &http.Server{
+		Addr:    s.addr,
+		Handler: mux,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err := server.Shutdown(shutdownCtx)
+		if err != nil {
+			logrus.Warnf("failed to shutdown the webhook server: %v", err)
+		}
+	}()
+
+	logrus.Infof("Webhook server listening on: %s", s.addr)
+	err := server.ListenAndServeTLS(s.certFile, s.keyFile)
+	if err != nil && err != http.ErrServerClosed {
+		return errors.Wrap(err, "serving the webhooks")
+	}
+	return nil
+}
+
+func (s *Server) serveValidate(w http.ResponseWriter, r *http.Request) {
+	serveAdmission(w, r, s.validate)
+}
+
+// validate admits the SSO resources which pass the same validation as the one done by the operator
+func (s *Server) validate(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Operation == admissionv1beta1.Delete {
+		return allowed()
+	}
+	sso := &v1.SSO{}
+	err := json.Unmarshal(req.Object.Raw, sso)
+	if err != nil {
+		return denied(apierrors.NewBadRequest(errors.Wrap(err, "decoding the SSO").Error()))
+	}
+	if sso.GetNamespace() == "" {
+		sso.SetNamespace(req.Namespace)
+	}
+	if sso.GetDeletionTimestamp() != nil {
+		return allowed()
+	}
+	errs := validation.ValidateSSO(sso, s.getService)
+	if len(errs) > 0 {
+		logrus.Infof("Rejecting SSO '%s/%s': %v", sso.GetNamespace(), sso.GetName(), errs.ToAggregate())
+		return denied(apierrors.NewInvalid(v1.SchemeGroupVersion.WithKind(v1.SSOKind).GroupKind(), sso.GetName(), errs))
+	}
+	return allowed()
+}
+
+func (s *Server) serveMutate(w http.ResponseWriter, r *http.Request) {
+	serveAdmission(w, r, s.mutate)
+}
+
+// mutate fills the empty fields of the SSO spec with the operator defaults, the whole spec is replaced by the patch
+func (s *Server) mutate(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Operation == admissionv1beta1.Delete || s.defaults == nil {
+		return allowed()
+	}
+	sso := &v1.SSO{}
+	err := json.Unmarshal(req.Object.Raw, sso)
+	if err != nil {
+		return denied(apierrors.NewBadRequest(errors.Wrap(err, "decoding the SSO").Error()))
+	}
+	if sso.GetName() == "" {
+		sso.SetName(req.Name)
+	}
+	if !s.defaults.Apply(sso) {
+		return allowed()
+	}
+	patch, err := json.Marshal([]map[string]interface{}{{
+		"op":    "add",
+		"path":  "/spec",
+		"value": sso.Spec,
+	}})
+	if err != nil {
+		return denied(apierrors.NewInternalError(errors.Wrap(err, "encoding the defaults patch")))
+	}
+	patchType := admissionv1beta1.PatchTypeJSONPatch
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patch,
+		PatchType: &patchType,
+	}
+}
+
+// serveAdmission decodes an admission review, and writes back the review with the response of the admission function.
+// The review is answered with the API version of the request, which is the same for v1 and v1beta1 reviews.
+func serveAdmission(w http.ResponseWriter, r *http.Request, admit func(*admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST requests are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, "reading the admission review: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	review := admissionv1beta1.AdmissionReview{}
+	err = json.Unmarshal(body, &review)
+	if err != nil || review.Request == nil {
+		http.Error(w, "decoding the admission review", http.StatusBadRequest)
+		return
+	}
+
+	response := admit(review.Request)
+	response.UID = review.Request.UID
+	review.Request = nil
+	review.Response = response
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(review)
+	if err != nil {
+		logrus.Errorf("failed to write the admission review: %v", err)
+	}
+}
+
+func allowed() *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+}
+
+func denied(err *apierrors.StatusError) *admissionv1beta1.AdmissionResponse {
+	status := err.Status()
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: false,
+		Result:  &status,
+	}
+}
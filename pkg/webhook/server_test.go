@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/jenkins-x/sso-operator/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/sso-operator/pkg/defaults"
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func review(t *testing.T, server *Server, sso *v1.SSO) *admissionv1beta1.AdmissionResponse {
+	return reviewAt(t, server.serveValidate, sso)
+}
+
+func reviewAt(t *testing.T, serve http.HandlerFunc, sso *v1.SSO) *admissionv1beta1.AdmissionResponse {
+	raw, err := json.Marshal(sso)
+	assert.NoError(t, err)
+	body, err := json.Marshal(admissionv1beta1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:       "7f0b2891-916f-4ed6-b7cd-27bff1815a8c",
+			Namespace: "jx-staging",
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	serve(recorder, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	result := admissionv1beta1.AdmissionReview{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, "admission.k8s.io/v1", result.APIVersion, "the review should be answered with the version of the request")
+	assert.Equal(t, "7f0b2891-916f-4ed6-b7cd-27bff1815a8c", string(result.Response.UID))
+	return result.Response
+}
+
+func TestValidate(t *testing.T) {
+	server := NewServer(":8443", "", "", nil, nil)
+	sso := &v1.SSO{
+		ObjectMeta: metav1.ObjectMeta{Name: "sso-golang-http"},
+		Spec: v1.SSOSpec{
+			OIDCIssuerURL:   "https://dex.jx-staging.example.com",
+			UpstreamService: "golang-http",
+			ProxyImage:      "quay.io/pusher/oauth2_proxy",
+			ProxyImageTag:   "v3.2.0",
+		},
+	}
+
+	response := review(t, server, sso)
+	assert.True(t, response.Allowed)
+
+	sso.Spec.OIDCIssuerURL = "http://dex.jx-staging.example.com"
+	response = review(t, server, sso)
+	assert.False(t, response.Allowed)
+	assert.Equal(t, metav1.StatusReasonInvalid, response.Result.Reason)
+	assert.Len(t, response.Result.Details.Causes, 1)
+	assert.Equal(t, "spec.oidcIssuerUrl", response.Result.Details.Causes[0].Field)
+}
+
+func TestServeAdmissionRejectsInvalidRequests(t *testing.T) {
+	server := NewServer(":8443", "", "", nil, nil)
+
+	recorder := httptest.NewRecorder()
+	server.serveValidate(recorder, httptest.NewRequest(http.MethodGet, ValidatePath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	server.serveValidate(recorder, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader([]byte("{"))))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestMutate(t *testing.T) {
+	server := NewServer(":8443", "", "", nil, &defaults.Defaults{
+		OIDCIssuerURL: "https://dex.jx.example.com",
+		ProxyImage:    "quay.io/pusher/oauth2_proxy",
+		ProxyImageTag: "v3.2.0",
+	})
+	sso := &v1.SSO{
+		ObjectMeta: metav1.ObjectMeta{Name: "sso-golang-http"},
+		Spec: v1.SSOSpec{
+			UpstreamService: "golang-http",
+			ProxyImageTag:   "v4.0.0",
+		},
+	}
+
+	response := reviewAt(t, server.serveMutate, sso)
+
+	assert.True(t, response.Allowed)
+	assert.Equal(t, admissionv1beta1.PatchTypeJSONPatch, *response.PatchType)
+	patch := []struct {
+		Op    string     `json:"op"`
+		Path  string     `json:"path"`
+		Value v1.SSOSpec `json:"value"`
+	}{}
+	err := json.Unmarshal(response.Patch, &patch)
+	assert.NoError(t, err)
+	assert.Len(t, patch, 1)
+	assert.Equal(t, "/spec", patch[0].Path)
+	assert.Equal(t, "https://dex.jx.example.com", patch[0].Value.OIDCIssuerURL)
+	assert.Equal(t, "quay.io/pusher/oauth2_proxy", patch[0].Value.ProxyImage)
+	assert.Equal(t, "v4.0.0", patch[0].Value.ProxyImageTag, "a field set in the SSO should be kept")
+	assert.Equal(t, "golang-http", patch[0].Value.UpstreamService)
+}